@@ -0,0 +1,230 @@
+package redis
+
+import (
+	"github.com/gomodule/redigo/redis"
+)
+
+// BitFieldOp is a BITFIELD subcommand, built with BitFieldGet, BitFieldSet,
+// BitFieldIncrBy and BitFieldOverflow
+type BitFieldOp struct {
+	op       string
+	encoding string
+	offset   int
+	value    int64
+	overflow string
+}
+
+// BitFieldGet reads the integer encoded as encoding, such as "u8" or "i16",
+// at offset in bits
+func BitFieldGet(encoding string, offset int) BitFieldOp {
+	return BitFieldOp{op: "GET", encoding: encoding, offset: offset}
+}
+
+// BitFieldSet writes value and replies with the previous value
+func BitFieldSet(encoding string, offset int, value int64) BitFieldOp {
+	return BitFieldOp{op: "SET", encoding: encoding, offset: offset, value: value}
+}
+
+// BitFieldIncrBy adds by and replies with the new value
+func BitFieldIncrBy(encoding string, offset int, by int64) BitFieldOp {
+	return BitFieldOp{op: "INCRBY", encoding: encoding, offset: offset, value: by}
+}
+
+// BitFieldOverflow sets how the next SET and INCRBY overflow: "WRAP" by
+// default, "SAT" or "FAIL"
+func BitFieldOverflow(overflow string) BitFieldOp {
+	return BitFieldOp{op: "OVERFLOW", overflow: overflow}
+}
+
+func (o BitFieldOp) args() []interface{} {
+	switch o.op {
+	case "OVERFLOW":
+		return []interface{}{o.op, o.overflow}
+	case "GET":
+		return []interface{}{o.op, o.encoding, o.offset}
+	default:
+		return []interface{}{o.op, o.encoding, o.offset, o.value}
+	}
+}
+
+// BitFieldValue is the reply of a GET, SET or INCRBY subcommand, Failed when
+// the write wasn't done because of an OVERFLOW FAIL
+type BitFieldValue struct {
+	Value  int64
+	Failed bool
+}
+
+// SetBit sets the bit at offset and returns its previous value
+func (c *RedisConnectionImpl) SetBit(key string, offset int, value bool) (bool, error) {
+	return redis.Bool(c.conn.Do("SETBIT", key, offset, bitArg(value)))
+}
+
+func (c *RedisConnectionImpl) GetBit(key string, offset int) (bool, error) {
+	return redis.Bool(c.conn.Do("GETBIT", key, offset))
+}
+
+// BitCount returns the number of bits set
+func (c *RedisConnectionImpl) BitCount(key string) (int, error) {
+	return redis.Int(c.conn.Do("BITCOUNT", key))
+}
+
+// BitOp stores the bitwise op, "AND", "OR", "XOR" or "NOT", of keys in dest
+// and returns its length in bytes
+func (c *RedisConnectionImpl) BitOp(op string, dest string, keys ...string) (int, error) {
+	return redis.Int(c.conn.Do("BITOP", append([]interface{}{op}, keyArgs(dest, keys)...)...))
+}
+
+// BitField runs ops on the integers stored in the bitmap at key, replying
+// for every op but OVERFLOW
+func (c *RedisConnectionImpl) BitField(key string, ops ...BitFieldOp) ([]BitFieldValue, error) {
+	return getBitFieldValues(c.conn.Do("BITFIELD", bitFieldArgs(key, ops)...))
+}
+
+func (p *PipelineImpl) SetBit(key string, offset int, value bool) *SetBitCmd {
+	cmd := SetBitCmd{
+		key:    key,
+		offset: offset,
+		bit:    value,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+func (p *PipelineImpl) GetBit(key string, offset int) *GetBitCmd {
+	cmd := GetBitCmd{
+		key:    key,
+		offset: offset,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+func (p *PipelineImpl) BitCount(key string) *BitCountCmd {
+	cmd := BitCountCmd{
+		key: key,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+func (p *PipelineImpl) BitOp(op string, dest string, keys ...string) *BitOpCmd {
+	cmd := BitOpCmd{
+		op:   op,
+		dest: dest,
+		keys: keys,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+func (p *PipelineImpl) BitField(key string, ops ...BitFieldOp) *BitFieldCmd {
+	cmd := BitFieldCmd{
+		key: key,
+		ops: ops,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+// SetBitCmd value is the previous value of the bit
+type SetBitCmd struct {
+	key    string
+	offset int
+	bit    bool
+	value  bool
+}
+
+func (s *SetBitCmd) Value() bool {
+	return s.value
+}
+
+type GetBitCmd struct {
+	key    string
+	offset int
+	value  bool
+}
+
+func (g *GetBitCmd) Value() bool {
+	return g.value
+}
+
+type BitCountCmd struct {
+	key   string
+	value int
+}
+
+func (b *BitCountCmd) Value() int {
+	return b.value
+}
+
+// BitOpCmd value is the length in bytes of dest
+type BitOpCmd struct {
+	op    string
+	dest  string
+	keys  []string
+	value int
+}
+
+func (b *BitOpCmd) Value() int {
+	return b.value
+}
+
+type BitFieldCmd struct {
+	key   string
+	ops   []BitFieldOp
+	value []BitFieldValue
+}
+
+func (b *BitFieldCmd) Value() []BitFieldValue {
+	return b.value
+}
+
+func bitArg(value bool) int {
+	if value {
+		return 1
+	}
+
+	return 0
+}
+
+func bitFieldArgs(key string, ops []BitFieldOp) []interface{} {
+	args := []interface{}{key}
+
+	for _, op := range ops {
+		args = append(args, op.args()...)
+	}
+
+	return args
+}
+
+func getBitFieldValues(value interface{}, err error) ([]BitFieldValue, error) {
+	values, err := redis.Values(value, err)
+
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]BitFieldValue, 0, len(values))
+
+	for _, value := range values {
+		if value == nil {
+			fields = append(fields, BitFieldValue{Failed: true})
+			continue
+		}
+
+		field, err := redis.Int64(value, nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, BitFieldValue{Value: field})
+	}
+
+	return fields, nil
+}
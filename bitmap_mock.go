@@ -0,0 +1,376 @@
+package redis
+
+import (
+	"math/big"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// getBytes returns the string at key as bytes, empty if missing. Lock must
+// be held.
+func (r *RedisMock) getBytes(key string) ([]byte, error) {
+	if r.failsOnGet[key] {
+		return nil, mockFailure("get")
+	}
+
+	obj := r.lookup(key)
+
+	if obj == nil {
+		return []byte{}, nil
+	}
+
+	b, ok := obj.data.([]byte)
+
+	if !ok {
+		return nil, mockWrongType()
+	}
+
+	return append([]byte{}, b...), nil
+}
+
+// storeBytes stores b as a string at key, keeping its ttl. Lock must be held.
+func (r *RedisMock) storeBytes(key string, b []byte) error {
+	if r.failsOnSet[key] {
+		return mockFailure("set")
+	}
+
+	if obj := r.lookup(key); obj != nil {
+		obj.data = b
+		return nil
+	}
+
+	r.db[key] = &RedisMockObject{data: b}
+	return nil
+}
+
+func (c *RedisConnectionMock) SetBit(key string, offset int, value bool) (bool, error) {
+	if offset < 0 {
+		return false, mockError("ERR bit offset is not an integer or out of range")
+	}
+
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
+	b, err := c.redis.getBytes(key)
+
+	if err != nil {
+		return false, err
+	}
+
+	b = growBits(b, offset+1)
+	previous := readBits(b, offset, 1) == 1
+	writeBits(b, offset, 1, uint64(bitArg(value)))
+
+	return previous, c.redis.storeBytes(key, b)
+}
+
+func (c *RedisConnectionMock) GetBit(key string, offset int) (bool, error) {
+	if offset < 0 {
+		return false, mockError("ERR bit offset is not an integer or out of range")
+	}
+
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
+	b, err := c.redis.getBytes(key)
+
+	if err != nil || offset >= 8*len(b) {
+		return false, err
+	}
+
+	return readBits(b, offset, 1) == 1, nil
+}
+
+func (c *RedisConnectionMock) BitCount(key string) (int, error) {
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
+	b, err := c.redis.getBytes(key)
+
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+
+	for _, octet := range b {
+		count += bits.OnesCount8(octet)
+	}
+
+	return count, nil
+}
+
+func (c *RedisConnectionMock) BitOp(op string, dest string, keys ...string) (int, error) {
+	op = strings.ToUpper(op)
+
+	if op == "NOT" && len(keys) != 1 {
+		return 0, mockError("ERR BITOP NOT must be called with a single source key.")
+	}
+
+	if op != "AND" && op != "OR" && op != "XOR" && op != "NOT" {
+		return 0, mockError("ERR syntax error")
+	}
+
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
+	sources := make([][]byte, 0, len(keys))
+	length := 0
+
+	for _, key := range keys {
+		b, err := c.redis.getBytes(key)
+
+		if err != nil {
+			return 0, err
+		}
+
+		if len(b) > length {
+			length = len(b)
+		}
+
+		sources = append(sources, b)
+	}
+
+	result := make([]byte, length)
+
+	for i := range result {
+		for j, source := range sources {
+			octet := byte(0)
+
+			// missing bytes are zeros
+			if i < len(source) {
+				octet = source[i]
+			}
+
+			switch {
+			case op == "NOT":
+				result[i] = ^octet
+			case j == 0:
+				result[i] = octet
+			case op == "AND":
+				result[i] &= octet
+			case op == "OR":
+				result[i] |= octet
+			case op == "XOR":
+				result[i] ^= octet
+			}
+		}
+	}
+
+	if length == 0 {
+		delete(c.redis.db, dest)
+		return 0, nil
+	}
+
+	if err := c.redis.set(dest, string(result), 0); err != nil {
+		return 0, err
+	}
+
+	return length, nil
+}
+
+func (c *RedisConnectionMock) BitField(key string, ops ...BitFieldOp) ([]BitFieldValue, error) {
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
+	b, err := c.redis.getBytes(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	values := []BitFieldValue{}
+	overflow := "WRAP"
+	written := false
+
+	for _, op := range ops {
+		if op.op == "OVERFLOW" {
+			overflow = strings.ToUpper(op.overflow)
+
+			if overflow != "WRAP" && overflow != "SAT" && overflow != "FAIL" {
+				return nil, mockError("ERR Invalid OVERFLOW type specified")
+			}
+
+			continue
+		}
+
+		signed, width, err := parseBitFieldEncoding(op.encoding)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if op.offset < 0 {
+			return nil, mockError("ERR bit offset is not an integer or out of range")
+		}
+
+		// bits past the end read as zeros, GET not growing the string
+		if op.op == "GET" {
+			padded := growBits(append([]byte{}, b...), op.offset+width)
+			values = append(values, BitFieldValue{Value: bitFieldValue(readBits(padded, op.offset, width), signed, width)})
+			continue
+		}
+
+		b = growBits(b, op.offset+width)
+		current := bitFieldValue(readBits(b, op.offset, width), signed, width)
+
+		switch op.op {
+		case "SET":
+			value, ok := bitFieldOverflow(big.NewInt(op.value), signed, width, overflow)
+
+			if !ok {
+				values = append(values, BitFieldValue{Failed: true})
+				continue
+			}
+
+			writeBits(b, op.offset, width, uint64(value))
+			written = true
+			values = append(values, BitFieldValue{Value: current})
+		case "INCRBY":
+			sum := new(big.Int).Add(big.NewInt(current), big.NewInt(op.value))
+			value, ok := bitFieldOverflow(sum, signed, width, overflow)
+
+			if ok {
+				writeBits(b, op.offset, width, uint64(value))
+				written = true
+			}
+
+			values = append(values, BitFieldValue{Value: value, Failed: !ok})
+		default:
+			return nil, mockError("ERR syntax error")
+		}
+	}
+
+	if written {
+		return values, c.redis.storeBytes(key, b)
+	}
+
+	return values, nil
+}
+
+// parseBitFieldEncoding parses encodings such as "i8" or "u16"
+func parseBitFieldEncoding(encoding string) (bool, int, error) {
+	if len(encoding) < 2 {
+		return false, 0, mockError("ERR Invalid bitfield type")
+	}
+
+	signed := encoding[0] == 'i' || encoding[0] == 'I'
+	width, err := strconv.Atoi(encoding[1:])
+
+	if err != nil || !signed && encoding[0] != 'u' && encoding[0] != 'U' || width < 1 || signed && width > 64 || !signed && width > 63 {
+		return false, 0, mockError("ERR Invalid bitfield type. Use something like i16 u8. Note that u64 is not supported but i64 is.")
+	}
+
+	return signed, width, nil
+}
+
+// bitFieldOverflow fits value in the encoding, as overflow does, or returns
+// false when it doesn't fit with FAIL
+func bitFieldOverflow(value *big.Int, signed bool, width int, overflow string) (int64, bool) {
+	min, max := big.NewInt(0), new(big.Int).Lsh(big.NewInt(1), uint(width))
+
+	if signed {
+		min.Neg(new(big.Int).Lsh(big.NewInt(1), uint(width-1)))
+		max.Lsh(big.NewInt(1), uint(width-1))
+	}
+
+	max.Sub(max, big.NewInt(1))
+
+	if value.Cmp(min) >= 0 && value.Cmp(max) <= 0 {
+		return value.Int64(), true
+	}
+
+	switch overflow {
+	case "SAT":
+		if value.Cmp(min) < 0 {
+			return min.Int64(), true
+		}
+
+		return max.Int64(), true
+	case "FAIL":
+		return 0, false
+	}
+
+	wrapped := new(big.Int).Mod(value, new(big.Int).Lsh(big.NewInt(1), uint(width)))
+	return bitFieldValue(wrapped.Uint64(), signed, width), true
+}
+
+// bitFieldValue interprets the width low bits of raw
+func bitFieldValue(raw uint64, signed bool, width int) int64 {
+	if signed && width < 64 && raw&(1<<uint(width-1)) != 0 {
+		return int64(raw) - int64(1)<<uint(width)
+	}
+
+	return int64(raw)
+}
+
+// growBits pads b with zeros to hold at least n bits
+func growBits(b []byte, n int) []byte {
+	for len(b)*8 < n {
+		b = append(b, 0)
+	}
+
+	return b
+}
+
+// readBits reads width bits at offset, bit 0 being the most significant bit
+// of the first byte as in redis
+func readBits(b []byte, offset int, width int) uint64 {
+	value := uint64(0)
+
+	for i := offset; i < offset+width; i++ {
+		value = value<<1 | uint64(b[i/8]>>(7-uint(i%8))&1)
+	}
+
+	return value
+}
+
+func writeBits(b []byte, offset int, width int, value uint64) {
+	for i := offset + width - 1; i >= offset; i-- {
+		mask := byte(1) << (7 - uint(i%8))
+
+		if value&1 == 1 {
+			b[i/8] |= mask
+		} else {
+			b[i/8] &^= mask
+		}
+
+		value >>= 1
+	}
+}
+
+func (p *PipelineMock) SetBit(key string, offset int, value bool) *SetBitCmd {
+	cmd := SetBitCmd{key: key, offset: offset, bit: value}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) GetBit(key string, offset int) *GetBitCmd {
+	cmd := GetBitCmd{key: key, offset: offset}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) BitCount(key string) *BitCountCmd {
+	cmd := BitCountCmd{key: key}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) BitOp(op string, dest string, keys ...string) *BitOpCmd {
+	cmd := BitOpCmd{op: op, dest: dest, keys: keys}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) BitField(key string, ops ...BitFieldOp) *BitFieldCmd {
+	cmd := BitFieldCmd{key: key, ops: ops}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
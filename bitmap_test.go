@@ -0,0 +1,74 @@
+package redis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBitmap(t *testing.T) {
+
+	t.Run("bits", func(t *testing.T) {
+		conn := MockRedis().Connection()
+
+		previous, err := conn.SetBit("active.mon", 7, true)
+		assert.Nil(t, err, "must succeed")
+		assert.False(t, previous)
+
+		conn.SetBit("active.mon", 9, true)
+		conn.SetBit("active.tue", 9, true)
+
+		value, _, _ := conn.GetString("active.mon")
+		assert.Equal(t, "\x01\x40", value, "bit 0 must be the most significant")
+
+		set, _ := conn.GetBit("active.mon", 7)
+		assert.True(t, set)
+
+		set, _ = conn.GetBit("active.mon", 100)
+		assert.False(t, set, "bits past the end must be unset")
+
+		pipe := conn.Pipeline()
+		both := pipe.BitOp("AND", "active.both", "active.mon", "active.tue")
+		count := pipe.BitCount("active.both")
+		monday := pipe.BitCount("active.mon")
+
+		assert.Nil(t, pipe.Exec(), "must succeed")
+		assert.Equal(t, 2, both.Value(), "length must be the longest source")
+		assert.Equal(t, 1, count.Value())
+		assert.Equal(t, 2, monday.Value())
+
+		_, err = conn.BitOp("NOT", "dest", "a", "b")
+		assert.NotNil(t, err, "NOT must take a single key")
+	})
+
+	t.Run("bitfield", func(t *testing.T) {
+		conn := MockRedis().Connection()
+
+		values, err := conn.BitField("counters",
+			BitFieldSet("u8", 0, 200),
+			BitFieldIncrBy("u8", 0, 100),
+			BitFieldOverflow("SAT"),
+			BitFieldIncrBy("u8", 0, 250),
+			BitFieldOverflow("FAIL"),
+			BitFieldIncrBy("u8", 0, 1),
+			BitFieldIncrBy("i4", 8, -9),
+			BitFieldGet("u16", 0),
+		)
+
+		assert.Nil(t, err, "must succeed")
+		assert.Equal(t, []BitFieldValue{
+			{Value: 0},
+			{Value: 44},
+			{Value: 255},
+			{Failed: true},
+			{Failed: true},
+			{Value: 255 << 8},
+		}, values)
+
+		pipe := conn.Pipeline()
+		field := pipe.BitField("counters", BitFieldIncrBy("i4", 8, -8), BitFieldGet("i4", 8))
+
+		assert.Nil(t, pipe.Exec(), "must succeed")
+		assert.Equal(t, []BitFieldValue{{Value: -8}, {Value: -8}}, field.Value())
+	})
+}
@@ -0,0 +1,248 @@
+package redis
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// ErrCircuitOpen is returned without sending commands while the circuit
+// breaker is open
+var ErrCircuitOpen = errors.New("redis: circuit breaker is open")
+
+type CircuitState int
+
+const (
+	// CircuitClosed lets every command through
+	CircuitClosed CircuitState = iota
+	// CircuitOpen fails every command fast
+	CircuitOpen
+	// CircuitHalfOpen lets a single probe through, closing the circuit if it
+	// succeeds and opening it again otherwise
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+
+	return "closed"
+}
+
+// CircuitBreakerOptions configure WithCircuitBreaker, zero values taking
+// defaults. Failures are network errors, timeouts, transient redis errors
+// and commands slower than SlowThreshold, not errors such as WRONGTYPE.
+type CircuitBreakerOptions struct {
+	// Window is the period over which the error rate is computed, 10s by
+	// default
+	Window time.Duration
+	// MinRequests is the min number of commands in a window to open the
+	// circuit, 20 by default
+	MinRequests int
+	// ErrorRate is the fraction of failed commands opening the circuit, 0.5
+	// by default
+	ErrorRate float64
+	// SlowThreshold counts the commands lasting longer as failed, 0 not
+	// counting slow commands
+	SlowThreshold time.Duration
+	// OpenTimeout is how long the circuit stays open before a probe, 30s by
+	// default
+	OpenTimeout time.Duration
+	// OnStateChange is called on every state change, if set
+	OnStateChange func(from CircuitState, to CircuitState)
+}
+
+// WithCircuitBreaker fails commands fast with ErrCircuitOpen when too many
+// fail or are slow
+func WithCircuitBreaker(options CircuitBreakerOptions) Option {
+	return func(o *redisOptions) {
+		o.breaker = newCircuitBreaker(options, time.Now)
+	}
+}
+
+type circuitBreaker struct {
+	mu      sync.Mutex
+	options CircuitBreakerOptions
+	now     func() time.Time
+
+	state       CircuitState
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+}
+
+func newCircuitBreaker(options CircuitBreakerOptions, now func() time.Time) *circuitBreaker {
+	if options.Window <= 0 {
+		options.Window = 10 * time.Second
+	}
+
+	if options.MinRequests <= 0 {
+		options.MinRequests = 20
+	}
+
+	if options.ErrorRate <= 0 {
+		options.ErrorRate = 0.5
+	}
+
+	if options.OpenTimeout <= 0 {
+		options.OpenTimeout = 30 * time.Second
+	}
+
+	return &circuitBreaker{
+		options:     options,
+		now:         now,
+		windowStart: now(),
+	}
+}
+
+// call runs fn unless the circuit is open, recording its outcome. A nil
+// breaker just runs fn.
+func (b *circuitBreaker) call(fn func() error) error {
+	if b == nil {
+		return fn()
+	}
+
+	if err := b.allow(); err != nil {
+		return err
+	}
+
+	start := b.now()
+	err := fn()
+	b.record(b.now().Sub(start), err)
+
+	return err
+}
+
+// rejects tells whether the circuit fails commands fast, without letting a
+// probe through. A nil breaker never rejects.
+func (b *circuitBreaker) rejects() bool {
+	if b == nil {
+		return false
+	}
+
+	now := b.now()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state == CircuitHalfOpen || b.state == CircuitOpen && now.Sub(b.openedAt) < b.options.OpenTimeout
+}
+
+func (b *circuitBreaker) allow() error {
+	now := b.now()
+
+	b.mu.Lock()
+	from := b.state
+
+	switch {
+	case b.state == CircuitHalfOpen,
+		b.state == CircuitOpen && now.Sub(b.openedAt) < b.options.OpenTimeout:
+		b.mu.Unlock()
+		return ErrCircuitOpen
+	case b.state == CircuitOpen:
+		b.state = CircuitHalfOpen
+	}
+
+	to := b.state
+	b.mu.Unlock()
+
+	b.changed(from, to)
+	return nil
+}
+
+func (b *circuitBreaker) record(duration time.Duration, err error) {
+	now := b.now()
+	failed := breakerFailure(err) || b.options.SlowThreshold > 0 && duration > b.options.SlowThreshold
+
+	b.mu.Lock()
+	from := b.state
+
+	switch b.state {
+	case CircuitHalfOpen:
+		if failed {
+			b.open(now)
+		} else {
+			b.state = CircuitClosed
+			b.reset(now)
+		}
+	case CircuitClosed:
+		if now.Sub(b.windowStart) >= b.options.Window {
+			b.reset(now)
+		}
+
+		b.requests++
+
+		if failed {
+			b.failures++
+		}
+
+		if b.requests >= b.options.MinRequests && float64(b.failures)/float64(b.requests) >= b.options.ErrorRate {
+			b.open(now)
+		}
+	}
+
+	to := b.state
+	b.mu.Unlock()
+
+	b.changed(from, to)
+}
+
+// open opens the circuit. Lock must be held.
+func (b *circuitBreaker) open(now time.Time) {
+	b.state = CircuitOpen
+	b.openedAt = now
+}
+
+// reset starts a new window. Lock must be held.
+func (b *circuitBreaker) reset(now time.Time) {
+	b.windowStart = now
+	b.requests = 0
+	b.failures = 0
+}
+
+func (b *circuitBreaker) changed(from CircuitState, to CircuitState) {
+	if from != to && b.options.OnStateChange != nil {
+		b.options.OnStateChange(from, to)
+	}
+}
+
+// getConn takes a conn from pool, failing fast with ErrCircuitOpen instead of
+// dialing while the circuit is open
+func getConn(pool *redis.Pool, breaker *circuitBreaker) redis.Conn {
+	if breaker.rejects() {
+		return errorConn{err: ErrCircuitOpen}
+	}
+
+	return pool.Get()
+}
+
+// errorConn fails every command with err, as redigo does for failed dials
+type errorConn struct {
+	err error
+}
+
+func (c errorConn) Close() error                                   { return nil }
+func (c errorConn) Err() error                                     { return c.err }
+func (c errorConn) Do(string, ...interface{}) (interface{}, error) { return nil, c.err }
+func (c errorConn) Send(string, ...interface{}) error              { return c.err }
+func (c errorConn) Flush() error                                   { return c.err }
+func (c errorConn) Receive() (interface{}, error)                  { return nil, c.err }
+
+// breakerFailure tells whether err hints at an unhealthy server, redis
+// replying errors such as WRONGTYPE being healthy
+func breakerFailure(err error) bool {
+	var redisErr redis.Error
+
+	if errors.As(err, &redisErr) {
+		return isTransient(err)
+	}
+
+	return err != nil
+}
@@ -0,0 +1,23 @@
+package redis
+
+import (
+	"time"
+)
+
+// WithCircuitBreaker guards the next commands of the mock, on every
+// connection including the open ones, with a circuit breaker following the
+// clock of the mock, see SetNow
+func (r *RedisMock) WithCircuitBreaker(options CircuitBreakerOptions) *RedisMock {
+	breaker := newCircuitBreaker(options, func() time.Time {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		return time.Unix(int64(r.now), 0)
+	})
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.breaker = breaker
+	return r
+}
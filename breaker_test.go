@@ -0,0 +1,123 @@
+package redis
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	changes := []string{}
+	r := MockRedis().SetNow(100).With("key", "value", 0).WithCircuitBreaker(CircuitBreakerOptions{
+		Window:      10 * time.Second,
+		MinRequests: 4,
+		ErrorRate:   0.5,
+		OpenTimeout: 30 * time.Second,
+		OnStateChange: func(from CircuitState, to CircuitState) {
+			changes = append(changes, from.String()+" -> "+to.String())
+		},
+	})
+	conn := r.Connection()
+
+	r.FailsTransiently("GET", 1, redis.Error("WRONGTYPE Operation against a key holding the wrong kind of value"))
+	conn.GetString("key")
+	conn.GetString("key")
+	r.FailsTransiently("GET", 1, nil)
+	conn.GetString("key")
+	assert.Empty(t, changes, "WRONGTYPE must not count as failure")
+
+	r.FailsTransiently("GET", 1, nil)
+	conn.GetString("key")
+	assert.Equal(t, []string{"closed -> open"}, changes, "2 failures out of 4 must open")
+
+	_, _, err := conn.GetString("key")
+	assert.True(t, errors.Is(err, ErrCircuitOpen), "must fail fast")
+
+	r.SetNow(130)
+	r.FailsTransiently("GET", 1, nil)
+	conn.GetString("key")
+	assert.Equal(t, []string{"closed -> open", "open -> half-open", "half-open -> open"}, changes, "failed probe must open")
+
+	_, _, err = conn.GetString("key")
+	assert.True(t, errors.Is(err, ErrCircuitOpen), "must fail fast")
+
+	r.SetNow(160)
+	value, _, err := conn.GetString("key")
+	assert.Nil(t, err, "probe must succeed")
+	assert.Equal(t, "value", value)
+	assert.Equal(t, []string{"open -> half-open", "half-open -> closed"}, changes[3:])
+
+	r.FailsTransiently("GET", 2, nil)
+	conn.GetString("key")
+	r.SetNow(175)
+	conn.GetString("key")
+	conn.GetString("key")
+	conn.GetString("key")
+	assert.Len(t, changes, 5, "failures of past windows must not count")
+}
+
+func TestCircuitBreakerSlow(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := newCircuitBreaker(CircuitBreakerOptions{MinRequests: 2, SlowThreshold: time.Second}, func() time.Time {
+		return now
+	})
+
+	slow := func() error {
+		now = now.Add(2 * time.Second)
+		return nil
+	}
+
+	assert.Nil(t, b.call(slow))
+	assert.Nil(t, b.call(slow))
+	assert.Equal(t, ErrCircuitOpen, b.call(slow), "slow commands must open")
+
+	var nilBreaker *circuitBreaker
+	assert.Nil(t, nilBreaker.call(func() error { return nil }), "nil breaker must run")
+}
+
+func TestCircuitBreakerDial(t *testing.T) {
+	dialed := make(chan struct{}, 10)
+	unblock := make(chan struct{})
+	defer close(unblock)
+
+	dial := func() (redis.Conn, error) {
+		dialed <- struct{}{}
+		<-unblock
+		return nil, errors.New("dial timeout")
+	}
+
+	r := &RedisImpl{
+		pool:    &redis.Pool{Dial: dial},
+		options: newRedisOptions([]Option{WithCircuitBreaker(CircuitBreakerOptions{}), WithPipelineChunks(1, 0), WithPipelineParallelism(2)}),
+	}
+	r.options.breaker.open(time.Now())
+
+	done := make(chan error, 2)
+
+	go func() {
+		conn := r.Connection()
+		defer conn.Close()
+
+		_, _, err := conn.GetString("a")
+		done <- err
+
+		pipe := conn.Pipeline()
+		pipe.GetString("a")
+		pipe.GetString("b")
+		done <- pipe.Exec()
+	}()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-done:
+			assert.ErrorIs(t, err, ErrCircuitOpen)
+		case <-time.After(time.Second):
+			t.Fatal("must fail fast without dialing")
+		}
+	}
+
+	assert.Empty(t, dialed, "must not dial while open")
+}
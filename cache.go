@@ -0,0 +1,232 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+type CacheOptions struct {
+	// NegativeTTL in sec caches ErrNotFound returned by loaders, 0 disables it
+	NegativeTTL int
+	// Jitter randomly spreads ttls by up to this ratio, e.g. 0.1 for +/-10%
+	Jitter float64
+	// StaleTTL in sec keeps serving values for this long past their ttl while
+	// they are reloaded in background, 0 disables it
+	StaleTTL int
+	// RefreshTimeout bounds the background reloads of stale values, 10s by
+	// default
+	RefreshTimeout time.Duration
+}
+
+type CacheLoader[T any] func(ctx context.Context) (T, error)
+
+// Cache is a read-through cache storing json encoded values of type T
+type Cache[T any] struct {
+	redis   Redis
+	options CacheOptions
+	flights flightGroup[T]
+}
+
+func NewCache[T any](r Redis, options CacheOptions) *Cache[T] {
+	if options.RefreshTimeout <= 0 {
+		options.RefreshTimeout = 10 * time.Second
+	}
+
+	return &Cache[T]{
+		redis:   r,
+		options: options,
+	}
+}
+
+// cacheEntry is the stored value along with its soft expiry
+type cacheEntry struct {
+	Value      json.RawMessage `json:"v,omitempty"`
+	NotFound   bool            `json:"nf,omitempty"`
+	FreshUntil int64           `json:"fu"`
+}
+
+// GetOrLoad returns the value cached at key, or loads and caches it for ttl
+// sec. Concurrent loads of a key within the process share the same loader
+// call, run with the context of the first caller. Stale values are reloaded
+// with the values of ctx but not its cancellation, for up to RefreshTimeout.
+func (c *Cache[T]) GetOrLoad(ctx context.Context, key string, ttl int, loader CacheLoader[T]) (T, error) {
+	var zero T
+
+	entry, now, err := c.get(key)
+
+	if err != nil {
+		return zero, err
+	}
+
+	if entry == nil {
+		return c.loadShared(ctx, key, ttl, loader)
+	}
+
+	if c.options.StaleTTL > 0 && now.Unix() >= entry.FreshUntil {
+		c.flights.do(key, func() (T, error) {
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.options.RefreshTimeout)
+			defer cancel()
+
+			return c.load(ctx, key, ttl, loader)
+		})
+	}
+
+	if entry.NotFound {
+		return zero, ErrNotFound
+	}
+
+	value := zero
+	err = json.Unmarshal(entry.Value, &value)
+
+	return value, err
+}
+
+func (c *Cache[T]) Delete(key string) error {
+	conn := c.redis.Connection()
+	defer conn.Close()
+
+	_, err := conn.Delete(key)
+	return err
+}
+
+func (c *Cache[T]) get(key string) (*cacheEntry, time.Time, error) {
+	conn := c.redis.Connection()
+	defer conn.Close()
+
+	pipe := conn.Pipeline()
+	get := pipe.GetString(key)
+	now := pipe.Time()
+
+	if err := pipe.Exec(); err != nil {
+		return nil, time.Time{}, err
+	}
+
+	if !get.Found() {
+		return nil, now.Value(), nil
+	}
+
+	entry := cacheEntry{}
+
+	// an unreadable entry is handled as a miss and overwritten by the load
+	if err := json.Unmarshal([]byte(get.Value()), &entry); err != nil {
+		return nil, now.Value(), nil
+	}
+
+	return &entry, now.Value(), nil
+}
+
+func (c *Cache[T]) loadShared(ctx context.Context, key string, ttl int, loader CacheLoader[T]) (T, error) {
+	call := c.flights.do(key, func() (T, error) {
+		return c.load(ctx, key, ttl, loader)
+	})
+
+	select {
+	case <-call.done:
+		return call.value, call.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// load runs the loader and caches its result. Caching is best effort, a
+// failure to store doesn't fail the load.
+func (c *Cache[T]) load(ctx context.Context, key string, ttl int, loader CacheLoader[T]) (T, error) {
+	value, err := loader(ctx)
+
+	if errors.Is(err, ErrNotFound) && c.options.NegativeTTL > 0 {
+		c.store(key, cacheEntry{NotFound: true}, c.options.NegativeTTL)
+	}
+
+	if err != nil {
+		return value, err
+	}
+
+	if data, err := json.Marshal(value); err == nil {
+		c.store(key, cacheEntry{Value: data}, ttl)
+	}
+
+	return value, nil
+}
+
+func (c *Cache[T]) store(key string, entry cacheEntry, ttl int) error {
+	conn := c.redis.Connection()
+	defer conn.Close()
+
+	now, err := conn.Time()
+
+	if err != nil {
+		return err
+	}
+
+	ttl = c.jitter(ttl)
+	entry.FreshUntil = now.Unix() + int64(ttl)
+
+	data, err := json.Marshal(entry)
+
+	if err != nil {
+		return err
+	}
+
+	return conn.SetString(key, string(data), ttl+c.options.StaleTTL)
+}
+
+func (c *Cache[T]) jitter(ttl int) int {
+	if c.options.Jitter <= 0 {
+		return ttl
+	}
+
+	ttl += int(float64(ttl) * c.options.Jitter * (2*rand.Float64() - 1))
+
+	if ttl < 1 {
+		return 1
+	}
+
+	return ttl
+}
+
+// flightGroup deduplicates concurrent calls sharing the same key
+type flightGroup[T any] struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall[T]
+}
+
+type flightCall[T any] struct {
+	done  chan struct{}
+	value T
+	err   error
+}
+
+// do starts fn in background unless a call for key is already running, and
+// returns the running call
+func (g *flightGroup[T]) do(key string, fn func() (T, error)) *flightCall[T] {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if call, ok := g.calls[key]; ok {
+		return call
+	}
+
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall[T])
+	}
+
+	call := &flightCall[T]{done: make(chan struct{})}
+	g.calls[key] = call
+
+	go func() {
+		call.value, call.err = fn()
+
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+
+		close(call.done)
+	}()
+
+	return call
+}
@@ -0,0 +1,126 @@
+package redis
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+
+	t.Run("loads once then hits", func(t *testing.T) {
+		r := MockRedis()
+		cache := NewCache[[]string](r, CacheOptions{})
+		calls := 0
+		loader := func(ctx context.Context) ([]string, error) {
+			calls++
+			return []string{"a", "b"}, nil
+		}
+
+		value, err := cache.GetOrLoad(context.Background(), "k", 10, loader)
+		assert.Nil(t, err, "must succeed")
+		assert.Equal(t, []string{"a", "b"}, value)
+
+		value, _ = cache.GetOrLoad(context.Background(), "k", 10, loader)
+		assert.Equal(t, []string{"a", "b"}, value)
+		assert.Equal(t, 1, calls, "second call must hit")
+
+		r.SetNow(10)
+		cache.GetOrLoad(context.Background(), "k", 10, loader)
+		assert.Equal(t, 2, calls, "expired value must be reloaded")
+	})
+
+	t.Run("deduplicates concurrent loads", func(t *testing.T) {
+		cache := NewCache[int](MockRedis(), CacheOptions{})
+		release := make(chan struct{})
+		var calls int32
+
+		loader := func(ctx context.Context) (int, error) {
+			atomic.AddInt32(&calls, 1)
+			<-release
+			return 42, nil
+		}
+
+		wg := sync.WaitGroup{}
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				value, err := cache.GetOrLoad(context.Background(), "k", 10, loader)
+				assert.Nil(t, err, "must succeed")
+				assert.Equal(t, 42, value)
+			}()
+		}
+
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "loader must run once")
+		value, _ := cache.GetOrLoad(context.Background(), "k", 10, loader)
+		assert.Equal(t, 42, value)
+	})
+
+	t.Run("caches not found", func(t *testing.T) {
+		cache := NewCache[string](MockRedis(), CacheOptions{NegativeTTL: 5})
+		calls := 0
+		loader := func(ctx context.Context) (string, error) {
+			calls++
+			return "", ErrNotFound
+		}
+
+		_, err := cache.GetOrLoad(context.Background(), "k", 10, loader)
+		assert.Equal(t, ErrNotFound, err)
+
+		_, err = cache.GetOrLoad(context.Background(), "k", 10, loader)
+		assert.Equal(t, ErrNotFound, err)
+		assert.Equal(t, 1, calls, "miss must be cached")
+	})
+
+	t.Run("serves stale while revalidating", func(t *testing.T) {
+		r := MockRedis()
+		cache := NewCache[string](r, CacheOptions{StaleTTL: 30, RefreshTimeout: 5 * time.Second})
+
+		cache.GetOrLoad(context.Background(), "k", 10, func(ctx context.Context) (string, error) {
+			return "v1", nil
+		})
+
+		stored := make(chan struct{})
+		r.WithHook(storeHook{stored: stored})
+		r.SetNow(20)
+
+		// the refresh must outlive the request serving the stale value
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		var deadline time.Time
+
+		value, err := cache.GetOrLoad(ctx, "k", 10, func(ctx context.Context) (string, error) {
+			deadline, _ = ctx.Deadline()
+			return "v2", ctx.Err()
+		})
+		assert.Nil(t, err, "must succeed")
+		assert.Equal(t, "v1", value, "stale value must be served")
+
+		<-stored
+		assert.WithinDuration(t, time.Now().Add(5*time.Second), deadline, time.Second, "refresh must time out")
+
+		value, _ = cache.GetOrLoad(context.Background(), "k", 10, nil)
+		assert.Equal(t, "v2", value, "value must be refreshed")
+	})
+}
+
+// storeHook closes stored once a value is stored
+type storeHook struct {
+	BaseHook
+	stored chan struct{}
+}
+
+func (h storeHook) AfterProcess(cmd *Command) {
+	if cmd.Name == "SETEX" {
+		close(h.stored)
+	}
+}
@@ -0,0 +1,110 @@
+package redis
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// chunkCmds splits cmds in chunks of at most maxCommands commands and about
+// maxBytes bytes, 0 applying no limit. A command bigger than maxBytes makes
+// a chunk on its own.
+func chunkCmds(cmds []interface{}, maxCommands int, maxBytes int) ([][]interface{}, error) {
+	if maxCommands <= 0 && maxBytes <= 0 {
+		return [][]interface{}{cmds}, nil
+	}
+
+	chunks := [][]interface{}{}
+	chunk := []interface{}{}
+	chunkSize := 0
+	sizer := &sizeConn{}
+
+	for _, cmd := range cmds {
+		size := 0
+
+		if maxBytes > 0 {
+			sizer.size = 0
+
+			if err := sendCmds(sizer, []interface{}{cmd}); err != nil {
+				return nil, err
+			}
+
+			size = sizer.size
+		}
+
+		full := maxCommands > 0 && len(chunk) >= maxCommands || maxBytes > 0 && chunkSize+size > maxBytes
+
+		if full && len(chunk) > 0 {
+			chunks = append(chunks, chunk)
+			chunk = []interface{}{}
+			chunkSize = 0
+		}
+
+		chunk = append(chunk, cmd)
+		chunkSize += size
+	}
+
+	if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
+	}
+
+	return chunks, nil
+}
+
+// sizeConn adds up the size of the commands sent as encoded by redis
+type sizeConn struct {
+	size int
+}
+
+func (c *sizeConn) Send(command string, args ...interface{}) error {
+	c.size += len(strconv.Itoa(len(args)+1)) + 3 + bulkSize(len(command))
+
+	for _, arg := range args {
+		c.size += bulkSize(argSize(arg))
+	}
+
+	return nil
+}
+
+func (c *sizeConn) Close() error {
+	return nil
+}
+
+func (c *sizeConn) Err() error {
+	return nil
+}
+
+func (c *sizeConn) Do(command string, args ...interface{}) (interface{}, error) {
+	return nil, c.Send(command, args...)
+}
+
+func (c *sizeConn) Flush() error {
+	return nil
+}
+
+func (c *sizeConn) Receive() (interface{}, error) {
+	return nil, nil
+}
+
+// bulkSize is the size of a "$<n>\r\n<data>\r\n" bulk string
+func bulkSize(n int) int {
+	return 1 + len(strconv.Itoa(n)) + 2 + n + 2
+}
+
+func argSize(arg interface{}) int {
+	switch arg := arg.(type) {
+	case string:
+		return len(arg)
+	case []byte:
+		return len(arg)
+	case int:
+		return len(strconv.Itoa(arg))
+	case int64:
+		return len(strconv.FormatInt(arg, 10))
+	case float64:
+		return len(strconv.FormatFloat(arg, 'g', -1, 64))
+	case nil:
+		return 0
+	}
+
+	return len(fmt.Sprint(arg))
+}
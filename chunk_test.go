@@ -0,0 +1,110 @@
+package redis
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+// echoConn replies to every command with its first arg, recording the
+// number of commands of each flush
+type echoConn struct {
+	mu      *sync.Mutex
+	flushes *[]int
+	pending []interface{}
+	replies []interface{}
+}
+
+func (c *echoConn) Send(command string, args ...interface{}) error {
+	c.pending = append(c.pending, []byte(fmt.Sprint(args[0])))
+	return nil
+}
+
+func (c *echoConn) Flush() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	*c.flushes = append(*c.flushes, len(c.pending))
+	c.replies = append(c.replies, c.pending...)
+	c.pending = nil
+
+	return nil
+}
+
+func (c *echoConn) Receive() (interface{}, error) {
+	reply := c.replies[0]
+	c.replies = c.replies[1:]
+
+	return reply, nil
+}
+
+func (c *echoConn) Do(command string, args ...interface{}) (interface{}, error) {
+	return nil, nil
+}
+
+func (c *echoConn) Close() error {
+	return nil
+}
+
+func (c *echoConn) Err() error {
+	return nil
+}
+
+func TestPipelineChunks(t *testing.T) {
+	run := func(options ...Option) ([]int, []string) {
+		mu := &sync.Mutex{}
+		flushes := []int{}
+		dial := func() (redis.Conn, error) {
+			return &echoConn{mu: mu, flushes: &flushes}, nil
+		}
+
+		r := &RedisImpl{pool: &redis.Pool{Dial: dial}, options: newRedisOptions(options)}
+		conn := r.Connection()
+		defer conn.Close()
+
+		pipe := conn.Pipeline()
+		cmds := []*GetStringCmd{}
+
+		for i := 0; i < 10; i++ {
+			cmds = append(cmds, pipe.GetString(fmt.Sprintf("k%d", i)))
+		}
+
+		assert.Nil(t, pipe.Exec(), "must succeed")
+
+		values := []string{}
+
+		for _, cmd := range cmds {
+			values = append(values, cmd.Value())
+		}
+
+		return flushes, values
+	}
+
+	expected := []string{"k0", "k1", "k2", "k3", "k4", "k5", "k6", "k7", "k8", "k9"}
+
+	flushes, values := run()
+	assert.Equal(t, []int{10}, flushes, "no limit by default")
+	assert.Equal(t, expected, values)
+
+	flushes, values = run(WithPipelineChunks(3, 0))
+	assert.Equal(t, []int{3, 3, 3, 1}, flushes)
+	assert.Equal(t, expected, values)
+
+	// GET kN is 21 bytes once encoded
+	flushes, values = run(WithPipelineChunks(0, 50))
+	assert.Equal(t, []int{2, 2, 2, 2, 2}, flushes)
+	assert.Equal(t, expected, values)
+
+	flushes, values = run(WithPipelineChunks(0, 10))
+	assert.Len(t, flushes, 10, "a command bigger than max bytes must be sent alone")
+	assert.Equal(t, expected, values)
+
+	flushes, values = run(WithPipelineChunks(4, 0), WithPipelineParallelism(2))
+	sort.Ints(flushes)
+	assert.Equal(t, []int{2, 4, 4}, flushes)
+	assert.Equal(t, expected, values, "values must be set on their cmd")
+}
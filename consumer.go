@@ -0,0 +1,190 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+type ConsumerOptions struct {
+	Stream string
+	Group  string
+	// Name identifies the consumer within the group
+	Name string
+	// Count is the max number of messages read at once, 10 by default
+	Count int
+	// Block waits up to Block for new messages, 0 doesn't wait
+	Block time.Duration
+	// ClaimIdle reclaims messages delivered to any consumer of the group and
+	// not acknowledged for at least ClaimIdle, such as the ones failing, a
+	// minute by default. Negative disables it, failed messages never being
+	// delivered again.
+	ClaimIdle time.Duration
+	// OnError is called with the messages handler fails on, left unacked,
+	// and the handler errors
+	OnError func(message XMessage, err error)
+}
+
+// Consumer reads messages of a stream as part of a consumer group, acking
+// the ones processed successfully
+type Consumer struct {
+	redis   Redis
+	options ConsumerOptions
+	mu      sync.Mutex
+	created bool
+}
+
+func NewConsumer(r Redis, options ConsumerOptions) *Consumer {
+	if options.Count <= 0 {
+		options.Count = 10
+	}
+
+	if options.ClaimIdle == 0 {
+		options.ClaimIdle = time.Minute
+	}
+
+	return &Consumer{
+		redis:   r,
+		options: options,
+	}
+}
+
+// Process runs handler on reclaimed then new messages and acks the ones
+// handled without error. It returns the number of messages acked and the
+// redis and handler errors joined, unacked messages being delivered again
+// once reclaimed.
+func (c *Consumer) Process(handler func(message XMessage) error) (int, error) {
+	var handlerErrs []error
+
+	num, err := c.process(func(message XMessage) error {
+		err := handler(message)
+
+		if err != nil {
+			handlerErrs = append(handlerErrs, err)
+		}
+
+		return err
+	})
+
+	return num, errors.Join(append([]error{err}, handlerErrs...)...)
+}
+
+// Run processes messages until ctx is done or redis fails, handler errors
+// being reported to OnError only
+func (c *Consumer) Run(ctx context.Context, handler func(message XMessage) error) error {
+	for ctx.Err() == nil {
+		num, err := c.process(handler)
+
+		if err != nil {
+			return err
+		}
+
+		// avoids spinning when reads don't block
+		if num == 0 && c.options.Block == 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(100 * time.Millisecond):
+			}
+		}
+	}
+
+	return nil
+}
+
+// process returns the number of messages acked and the redis errors,
+// reporting handler errors to OnError
+func (c *Consumer) process(handler func(message XMessage) error) (int, error) {
+	conn := c.redis.Connection()
+	defer conn.Close()
+
+	if err := c.createGroup(conn); err != nil {
+		return 0, err
+	}
+
+	messages, err := c.reclaim(conn)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if len(messages) == 0 {
+		streams, err := conn.XReadGroup(c.options.Group, c.options.Name, XReadOptions{
+			Streams: []string{c.options.Stream},
+			IDs:     []string{">"},
+			Count:   c.options.Count,
+			Block:   c.options.Block,
+		})
+
+		if err != nil {
+			return 0, err
+		}
+
+		for _, stream := range streams {
+			messages = append(messages, stream.Messages...)
+		}
+	}
+
+	acks := []string{}
+
+	for _, message := range messages {
+		if err := handler(message); err != nil {
+			if c.options.OnError != nil {
+				c.options.OnError(message, err)
+			}
+
+			continue
+		}
+
+		acks = append(acks, message.ID)
+	}
+
+	if len(acks) == 0 {
+		return 0, nil
+	}
+
+	return conn.XAck(c.options.Stream, c.options.Group, acks...)
+}
+
+// createGroup creates the group and the stream on first use, reading from
+// the start of the stream
+func (c *Consumer) createGroup(conn RedisConnection) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.created {
+		return nil
+	}
+
+	err := conn.XGroupCreate(c.options.Stream, c.options.Group, "0", true)
+
+	if err != nil && !IsBusyGroup(err) {
+		return err
+	}
+
+	c.created = true
+	return nil
+}
+
+func (c *Consumer) reclaim(conn RedisConnection) ([]XMessage, error) {
+	if c.options.ClaimIdle <= 0 {
+		return []XMessage{}, nil
+	}
+
+	entries, err := conn.XPending(c.options.Stream, c.options.Group, XPendingOptions{
+		Idle:  c.options.ClaimIdle,
+		Count: c.options.Count,
+	})
+
+	if err != nil || len(entries) == 0 {
+		return []XMessage{}, err
+	}
+
+	ids := make([]string, 0, len(entries))
+
+	for _, entry := range entries {
+		ids = append(ids, entry.ID)
+	}
+
+	return conn.XClaim(c.options.Stream, c.options.Group, c.options.Name, c.options.ClaimIdle, ids...)
+}
@@ -0,0 +1,64 @@
+package redis
+
+import (
+	"github.com/gomodule/redigo/redis"
+)
+
+// Reply is the raw reply of a command sent with Do
+type Reply struct {
+	value interface{}
+}
+
+// Value returns the reply as decoded by redigo: int64, []byte, []interface{}
+// or nil
+func (r Reply) Value() interface{} {
+	return r.value
+}
+
+// IsNil tells whether the reply is nil, such as a GET of a missing key
+func (r Reply) IsNil() bool {
+	return r.value == nil
+}
+
+func (r Reply) Int() (int, error) {
+	return redis.Int(r.value, nil)
+}
+
+func (r Reply) String() (string, error) {
+	return redis.String(r.value, nil)
+}
+
+func (r Reply) Strings() ([]string, error) {
+	return redis.Strings(r.value, nil)
+}
+
+// Map reads a reply of alternating fields and values, such as HGETALL's
+func (r Reply) Map() (map[string]string, error) {
+	return redis.StringMap(r.value, nil)
+}
+
+// Do sends a command the wrapper doesn't expose
+func (c *RedisConnectionImpl) Do(command string, args ...interface{}) (Reply, error) {
+	value, err := c.conn.Do(command, args...)
+	return Reply{value: value}, err
+}
+
+func (p *PipelineImpl) Do(command string, args ...interface{}) *DoCmd {
+	cmd := DoCmd{
+		command: command,
+		args:    args,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+type DoCmd struct {
+	command string
+	args    []interface{}
+	value   Reply
+}
+
+func (d *DoCmd) Value() Reply {
+	return d.value
+}
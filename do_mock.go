@@ -0,0 +1,104 @@
+package redis
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MockCommand emulates a command sent with Do on a mock connection. Like
+// MockScript, it runs without holding the mock lock. It replies as redigo
+// decodes replies: int64, string or []byte, []interface{} or nil.
+type MockCommand func(conn *RedisConnectionMock, args []interface{}) (interface{}, error)
+
+// WithCommand handles command, case insensitive, with fn
+func (r *RedisMock) WithCommand(command string, fn MockCommand) *RedisMock {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.commands[strings.ToUpper(command)] = fn
+	return r
+}
+
+// mockCommands are the commands handled by default
+func mockCommands() map[string]MockCommand {
+	return map[string]MockCommand{
+		"PING": func(conn *RedisConnectionMock, args []interface{}) (interface{}, error) {
+			if len(args) > 0 {
+				return fmt.Sprint(args[0]), nil
+			}
+
+			return "PONG", nil
+		},
+		"GET": func(conn *RedisConnectionMock, args []interface{}) (interface{}, error) {
+			if len(args) != 1 {
+				return nil, mockError("ERR wrong number of arguments for 'get' command")
+			}
+
+			conn.redis.mu.Lock()
+			defer conn.redis.mu.Unlock()
+
+			if conn.redis.lookup(fmt.Sprint(args[0])) == nil {
+				return nil, nil
+			}
+
+			return conn.redis.getBytes(fmt.Sprint(args[0]))
+		},
+		"EXISTS": func(conn *RedisConnectionMock, args []interface{}) (interface{}, error) {
+			num := int64(0)
+
+			for _, key := range args {
+				found, err := conn.Exists(fmt.Sprint(key))
+
+				if err != nil {
+					return nil, err
+				}
+
+				if found {
+					num++
+				}
+			}
+
+			return num, nil
+		},
+		"DEL": func(conn *RedisConnectionMock, args []interface{}) (interface{}, error) {
+			num, err := conn.Delete(mockCommandKeys(args)...)
+			return int64(num), err
+		},
+		"HSET":    mockHSet,
+		"HGET":    mockHGet,
+		"HDEL":    mockHDel,
+		"HGETALL": mockHGetAll,
+	}
+}
+
+func mockCommandKeys(args []interface{}) []string {
+	keys := make([]string, 0, len(args))
+
+	for _, arg := range args {
+		keys = append(keys, fmt.Sprint(arg))
+	}
+
+	return keys
+}
+
+// Do runs the handler registered for command, failing as redis does for
+// unknown commands
+func (c *RedisConnectionMock) Do(command string, args ...interface{}) (Reply, error) {
+	c.redis.mu.Lock()
+	fn := c.redis.commands[strings.ToUpper(command)]
+	c.redis.mu.Unlock()
+
+	if fn == nil {
+		return Reply{}, mockError(fmt.Sprintf("ERR unknown command '%s'", command))
+	}
+
+	value, err := fn(c, args)
+	return Reply{value: value}, err
+}
+
+func (p *PipelineMock) Do(command string, args ...interface{}) *DoCmd {
+	cmd := DoCmd{command: command, args: args}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
@@ -0,0 +1,88 @@
+package redis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDo(t *testing.T) {
+	r := MockRedis()
+	conn := r.Connection()
+
+	conn.SetString("a", "1", 0)
+
+	reply, err := conn.Do("get", "a")
+	assert.Nil(t, err, "must succeed")
+	value, _ := reply.String()
+	assert.Equal(t, "1", value)
+
+	reply, _ = conn.Do("GET", "missing")
+	assert.True(t, reply.IsNil(), "missing key must be nil")
+
+	_, err = conn.Do("OBJECT", "ENCODING", "a")
+	assert.Equal(t, "ERR unknown command 'OBJECT'", err.Error())
+
+	r.WithCommand("object", func(conn *RedisConnectionMock, args []interface{}) (interface{}, error) {
+		return []byte("embstr"), nil
+	})
+
+	pipe := conn.Pipeline()
+	encoding := pipe.Do("OBJECT", "ENCODING", "a")
+	deleted := pipe.Do("DEL", "a", "b")
+
+	assert.Nil(t, pipe.Exec(), "must succeed")
+	value, _ = encoding.Value().String()
+	assert.Equal(t, "embstr", value)
+	num, _ := deleted.Value().Int()
+	assert.Equal(t, 1, num)
+}
+
+func TestDoHash(t *testing.T) {
+	r := MockRedis()
+	conn := r.Connection()
+
+	reply, err := conn.Do("HSET", "h", "f1", "v1", "f2", 2)
+	assert.Nil(t, err, "must succeed")
+	num, _ := reply.Int()
+	assert.Equal(t, 2, num)
+
+	reply, _ = conn.Do("HGET", "h", "f2")
+	value, _ := reply.String()
+	assert.Equal(t, "2", value)
+
+	reply, _ = conn.Do("HGET", "h", "missing")
+	assert.True(t, reply.IsNil(), "missing field must be nil")
+
+	reply, _ = conn.Do("HGETALL", "h")
+	m, _ := reply.Map()
+	assert.Equal(t, map[string]string{"f1": "v1", "f2": "2"}, m)
+
+	r.FailsOnSet("other", true)
+	_, err = conn.Do("HSET", "other", "f", "v")
+	assert.NotNil(t, err, "must fail on set")
+
+	reply, _ = conn.Do("HDEL", "h", "f1", "f2", "f3")
+	num, _ = reply.Int()
+	assert.Equal(t, 2, num)
+	assert.Equal(t, 0, r.GetNumKeys(), "empty hash must be removed")
+
+	conn.SetString("s", "a", 0)
+	_, err = conn.Do("HGET", "s", "f")
+	assert.ErrorIs(t, err, ErrWrongType)
+}
+
+func TestReply(t *testing.T) {
+	reply := Reply{value: []interface{}{[]byte("f1"), []byte("v1"), []byte("f2"), []byte("v2")}}
+
+	strs, err := reply.Strings()
+	assert.Nil(t, err, "must succeed")
+	assert.Equal(t, []string{"f1", "v1", "f2", "v2"}, strs)
+
+	m, err := reply.Map()
+	assert.Nil(t, err, "must succeed")
+	assert.Equal(t, map[string]string{"f1": "v1", "f2": "v2"}, m)
+
+	_, err = Reply{value: []byte("x")}.Int()
+	assert.NotNil(t, err, "must fail")
+}
@@ -0,0 +1,134 @@
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// Errors matched with errors.Is, connections, pipelines and RedisMock
+// returning the same ones
+var (
+	// ErrNotFound is returned when a value doesn't exist, such as by cache
+	// loaders so that the miss itself can be cached
+	ErrNotFound = errors.New("redis: not found")
+	// ErrConnection matches network errors and failures to get a connection
+	ErrConnection = errors.New("redis: connection error")
+	// ErrTimeout matches network timeouts
+	ErrTimeout = errors.New("redis: timeout")
+	// ErrWrongType matches WRONGTYPE replies, for a key holding another type
+	ErrWrongType = errors.New("redis: wrong type")
+	// ErrUnsupportedCommand matches unknown commands, of redis or the mock
+	ErrUnsupportedCommand = errors.New("redis: unsupported command")
+	// ErrScript matches the errors of lua scripts and their emulations
+	ErrScript = errors.New("redis: script error")
+	// ErrPipeline matches PipelineError
+	ErrPipeline = errors.New("redis: pipeline error")
+)
+
+// CommandError is the error of a command, matching the error of its kind
+// with errors.Is and the error of redigo, such as redis.Error replies, with
+// errors.As
+type CommandError struct {
+	Err error
+}
+
+func (e *CommandError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+func (e *CommandError) Is(target error) bool {
+	return target != nil && errorKind(e.Err) == target
+}
+
+// PipelineError is the first error of a pipeline, the commands after it
+// still being run unless the connection broke
+type PipelineError struct {
+	// Index is the index of the failed command in the pipeline, Command its
+	// name
+	Index   int
+	Command string
+	Err     error
+}
+
+func (e *PipelineError) Error() string {
+	return fmt.Sprintf("redis: pipeline command %d (%s): %v", e.Index, e.Command, e.Err)
+}
+
+func (e *PipelineError) Unwrap() error {
+	return e.Err
+}
+
+func (e *PipelineError) Is(target error) bool {
+	return target == ErrPipeline
+}
+
+// commandError wraps err, unless nil or already wrapped
+func commandError(err error) error {
+	var commandErr *CommandError
+
+	if err == nil || errors.As(err, &commandErr) {
+		return err
+	}
+
+	return &CommandError{Err: err}
+}
+
+// pipelineError wraps err, the error of cmd, a *XxxCmd at index
+func pipelineError(index int, cmd interface{}, err error) error {
+	recorder := &commandConn{}
+	sendCmds(recorder, []interface{}{cmd})
+
+	name := ""
+
+	if len(recorder.cmds) > 0 {
+		name = recorder.cmds[0].Name
+	}
+
+	return &PipelineError{Index: index, Command: name, Err: commandError(err)}
+}
+
+// mockError is the error of a redis reply, as returned by redigo
+func mockError(reply string) error {
+	return &CommandError{Err: redis.Error(reply)}
+}
+
+// errorKind returns the error matching err, nil if none
+func errorKind(err error) error {
+	var redisErr redis.Error
+
+	if errors.As(err, &redisErr) {
+		reply := string(redisErr)
+
+		switch {
+		case strings.HasPrefix(reply, "WRONGTYPE"):
+			return ErrWrongType
+		case strings.HasPrefix(reply, "NOSCRIPT"), strings.HasPrefix(reply, "BUSY "),
+			strings.HasPrefix(reply, "ERR Error running script"), strings.HasPrefix(reply, "ERR Error compiling script"):
+			return ErrScript
+		case strings.HasPrefix(reply, "ERR unknown command"):
+			return ErrUnsupportedCommand
+		}
+
+		return nil
+	}
+
+	var netErr net.Error
+
+	switch {
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return ErrTimeout
+	case errors.As(err, &netErr), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, redis.ErrPoolExhausted):
+		return ErrConnection
+	}
+
+	return nil
+}
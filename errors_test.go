@@ -0,0 +1,121 @@
+package redis
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+// timeoutError is a network timeout
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+// repliesConn replies to the flushed commands with replies, in order
+type repliesConn struct {
+	echoConn
+	replies []interface{}
+}
+
+func (c *repliesConn) Receive() (interface{}, error) {
+	reply := c.replies[0]
+	c.replies = c.replies[1:]
+
+	if err, ok := reply.(redis.Error); ok {
+		return nil, err
+	}
+
+	return reply, nil
+}
+
+func TestErrors(t *testing.T) {
+	t.Run("kinds", func(t *testing.T) {
+		assert.ErrorIs(t, commandError(redis.Error("WRONGTYPE Operation against a key holding the wrong kind of value")), ErrWrongType)
+		assert.ErrorIs(t, commandError(redis.Error("NOSCRIPT No matching script")), ErrScript)
+		assert.ErrorIs(t, commandError(redis.Error("ERR Error running script (call to f_1): boom")), ErrScript)
+		assert.ErrorIs(t, commandError(redis.Error("ERR Error compiling script (new function): user_script:1: unexpected symbol")), ErrScript)
+		assert.ErrorIs(t, commandError(redis.Error("BUSY Redis is busy running a script.")), ErrScript)
+		assert.NotErrorIs(t, commandError(redis.Error("ERR invalid script flag")), ErrScript, "must not match any reply mentioning scripts")
+		assert.NotErrorIs(t, commandError(redis.Error("quota exceeded by script")), ErrScript, "errors thrown by scripts are theirs")
+		assert.ErrorIs(t, commandError(redis.Error("ERR unknown command 'FOO'")), ErrUnsupportedCommand)
+		assert.ErrorIs(t, commandError(io.EOF), ErrConnection)
+		assert.ErrorIs(t, commandError(&net.OpError{Op: "dial", Err: errors.New("connection refused")}), ErrConnection)
+		assert.ErrorIs(t, commandError(timeoutError{}), ErrTimeout)
+		assert.NotErrorIs(t, commandError(timeoutError{}), ErrConnection)
+		assert.NotErrorIs(t, commandError(redis.Error("ERR syntax error")), ErrWrongType)
+
+		var redisErr redis.Error
+		assert.True(t, errors.As(commandError(redis.Error("ERR syntax error")), &redisErr), "redigo error must be kept")
+		assert.Equal(t, "ERR syntax error", string(redisErr))
+	})
+
+	t.Run("pipeline", func(t *testing.T) {
+		dial := func() (redis.Conn, error) {
+			return &repliesConn{
+				echoConn: echoConn{mu: &sync.Mutex{}, flushes: &[]int{}},
+				replies:  []interface{}{[]byte("1"), []byte("2"), redis.Error("WRONGTYPE Operation against a key holding the wrong kind of value"), []byte("4")},
+			}, nil
+		}
+
+		r := &RedisImpl{pool: &redis.Pool{Dial: dial}, options: newRedisOptions([]Option{WithPipelineChunks(2, 0)})}
+		conn := r.Connection()
+		defer conn.Close()
+
+		pipe := conn.Pipeline()
+		pipe.GetString("a")
+		pipe.GetString("b")
+		pipe.IncrBy("c", 1)
+		sameChunk := pipe.GetString("d")
+		nextChunk := pipe.GetString("e")
+		err := pipe.Exec()
+
+		var pipelineErr *PipelineError
+		assert.True(t, errors.As(err, &pipelineErr), "must be a pipeline error")
+		assert.Equal(t, 2, pipelineErr.Index, "index must be in the pipeline, not the chunk")
+		assert.Equal(t, "INCRBY", pipelineErr.Command)
+		assert.ErrorIs(t, err, ErrPipeline)
+		assert.ErrorIs(t, err, ErrWrongType)
+		assert.Equal(t, "4", sameChunk.Value(), "replies after the error must be read")
+		assert.Equal(t, "", nextChunk.Value(), "chunks after a failed one must not run")
+	})
+
+	t.Run("mock", func(t *testing.T) {
+		r := MockRedis().With("s", "value", 0).FailsOnGet("broken", true)
+		conn := r.Connection()
+
+		_, err := conn.PFAdd("s", "a")
+		assert.ErrorIs(t, err, ErrWrongType)
+
+		var redisErr redis.Error
+		assert.True(t, errors.As(err, &redisErr), "mock must reply redis errors as redigo does")
+
+		_, _, err = conn.GetString("broken")
+		assert.ErrorIs(t, err, ErrConnection)
+
+		_, err = conn.Do("FOO")
+		assert.ErrorIs(t, err, ErrUnsupportedCommand)
+
+		_, err = conn.Eval(NewScript(0, "return 1"), nil)
+		assert.ErrorIs(t, err, ErrScript)
+
+		pipe := conn.Pipeline()
+		pipe.GetString("s")
+		pipe.PFCount("s")
+		after := pipe.GetString("s")
+		err = pipe.Exec()
+
+		var pipelineErr *PipelineError
+		assert.True(t, errors.As(err, &pipelineErr), "must be a pipeline error")
+		assert.Equal(t, 1, pipelineErr.Index)
+		assert.Equal(t, "PFCOUNT", pipelineErr.Command)
+		assert.ErrorIs(t, err, ErrWrongType)
+		assert.Equal(t, "value", after.Value(), "commands after the error must run")
+	})
+}
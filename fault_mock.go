@@ -0,0 +1,185 @@
+package redis
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// FaultKind is the failure injected by a Fault
+type FaultKind int
+
+const (
+	// FaultError fails the command with Err, a connection error if nil
+	FaultError FaultKind = iota
+	// FaultLatency delays the command by Latency, then runs it
+	FaultLatency
+	// FaultTimeout fails the command with a network timeout after Latency
+	FaultTimeout
+	// FaultDrop drops the connection, failing the command and the rest of
+	// its pipeline with io.EOF
+	FaultDrop
+)
+
+// Fault describes a failure injected in the commands of the mock matching
+// Command and Key. Every Latency delays the command, whatever the kind.
+type Fault struct {
+	Kind FaultKind
+	// Command is the name of the commands failed, any if empty. EVAL and
+	// EVALSHA match every script, as scripts are sent with either.
+	Command string
+	// Key is a glob pattern on the key of the commands failed, any if empty
+	Key string
+	// Nth fails only the nth matching command, counting from 1, every one if
+	// 0
+	Nth int
+	// Probability fails matching commands randomly, every one if 0
+	Probability float64
+	Latency     time.Duration
+	Err         error
+}
+
+// FiredFault is a fault fired on a command
+type FiredFault struct {
+	Fault   Fault
+	Command string
+	Args    []interface{}
+}
+
+// mockFault counts the commands matched by a fault
+type mockFault struct {
+	Fault
+	matched int
+}
+
+// mockTimeoutError is the network timeout of FaultTimeout
+type mockTimeoutError struct{}
+
+func (mockTimeoutError) Error() string   { return "i/o timeout" }
+func (mockTimeoutError) Timeout() bool   { return true }
+func (mockTimeoutError) Temporary() bool { return true }
+
+// InjectFault fails the next commands of the mock as fault describes, on
+// every connection including the open ones. Probabilities are drawn from a source seeded with 1, so
+// that tests are reproducible.
+func (r *RedisMock) InjectFault(fault Fault) *RedisMock {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.faults = append(r.faults, &mockFault{Fault: fault})
+	return r
+}
+
+// ClearFaults removes the injected faults, keeping the ones fired
+func (r *RedisMock) ClearFaults() *RedisMock {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.faults = nil
+	return r
+}
+
+// FiredFaults returns the faults fired, in order
+func (r *RedisMock) FiredFaults() []FiredFault {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]FiredFault{}, r.fired...)
+}
+
+// injectFault fires the first fault matching command, returning the error
+// failing it and whether the connection dropped
+func (r *RedisMock) injectFault(command *Command) (bool, error) {
+	r.mu.Lock()
+	fault := r.matchFault(command)
+	r.mu.Unlock()
+
+	if fault == nil {
+		return false, nil
+	}
+
+	time.Sleep(fault.Latency)
+
+	switch fault.Kind {
+	case FaultLatency:
+		return false, nil
+	case FaultTimeout:
+		return false, commandError(mockTimeoutError{})
+	case FaultDrop:
+		return true, commandError(io.EOF)
+	}
+
+	if fault.Err == nil {
+		return false, fmt.Errorf("%w: injected fault on %s", ErrConnection, command.Name)
+	}
+
+	return false, commandError(fault.Err)
+}
+
+// matchFault returns the fault fired by command, if any. Lock must be held.
+func (r *RedisMock) matchFault(command *Command) *Fault {
+	key, hasKey := commandKey(command)
+
+	for _, fault := range r.faults {
+		if len(fault.Command) > 0 && !sameCommand(fault.Command, command.Name) {
+			continue
+		}
+
+		if len(fault.Key) > 0 && (!hasKey || !globMatch(fault.Key, key)) {
+			continue
+		}
+
+		fault.matched++
+
+		if fault.Nth > 0 && fault.matched != fault.Nth {
+			continue
+		}
+
+		if fault.Probability > 0 && r.random.Float64() >= fault.Probability {
+			continue
+		}
+
+		r.fired = append(r.fired, FiredFault{Fault: fault.Fault, Command: command.Name, Args: command.Args})
+		return &fault.Fault
+	}
+
+	return nil
+}
+
+// sameCommand tells whether a and b name the same command, EVAL and EVALSHA
+// both running a script
+func sameCommand(a string, b string) bool {
+	script := func(name string) bool {
+		return strings.EqualFold(name, "EVAL") || strings.EqualFold(name, "EVALSHA")
+	}
+
+	return strings.EqualFold(a, b) || script(a) && script(b)
+}
+
+// commandKey returns the first key of command, its first arg but for
+// scripts
+func commandKey(command *Command) (string, bool) {
+	args := command.Args
+
+	switch strings.ToUpper(command.Name) {
+	case "EVAL", "EVALSHA":
+		if len(args) < 3 || fmt.Sprint(args[1]) == "0" {
+			return "", false
+		}
+
+		args = args[2:]
+	}
+
+	if len(args) == 0 {
+		return "", false
+	}
+
+	return fmt.Sprint(args[0]), true
+}
+
+// newMockRandom returns the source of the probabilities of faults
+func newMockRandom() *rand.Rand {
+	return rand.New(rand.NewSource(1))
+}
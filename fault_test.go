@@ -0,0 +1,134 @@
+package redis
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFault(t *testing.T) {
+
+	t.Run("match", func(t *testing.T) {
+		r := MockRedis().
+			With("user:1", "a", 0).
+			With("order:1", "b", 0).
+			InjectFault(Fault{Command: "get", Key: "user:*"})
+		conn := r.Connection()
+
+		_, _, err := conn.GetString("user:1")
+		assert.ErrorIs(t, err, ErrConnection)
+
+		value, _, err := conn.GetString("order:1")
+		assert.Nil(t, err, "other keys must succeed")
+		assert.Equal(t, "b", value)
+
+		_, err = conn.Exists("user:1")
+		assert.Nil(t, err, "other commands must succeed")
+
+		fired := r.FiredFaults()
+		assert.Equal(t, 1, len(fired))
+		assert.Equal(t, "GET", fired[0].Command)
+		assert.Equal(t, []interface{}{"user:1"}, fired[0].Args)
+		assert.Equal(t, "user:*", fired[0].Fault.Key)
+	})
+
+	t.Run("scripts", func(t *testing.T) {
+		r := MockRedis()
+		conn := r.Connection()
+		r.InjectFault(Fault{Kind: FaultTimeout, Command: "EVALSHA", Key: "rl:*"})
+		limiter := NewFixedWindowLimiter(1, time.Second)
+
+		_, err := limiter.Allow("rl:1", conn)
+		assert.ErrorIs(t, err, ErrTimeout, "EVALSHA must match scripts, on open connections too")
+
+		_, err = limiter.Allow("other", conn)
+		assert.Nil(t, err, "other keys must succeed")
+		assert.Equal(t, "EVAL", r.FiredFaults()[0].Command)
+	})
+
+	t.Run("nth", func(t *testing.T) {
+		r := MockRedis().InjectFault(Fault{Command: "INCRBY", Nth: 2, Err: redis.Error("OOM command not allowed")})
+		conn := r.Connection()
+
+		_, err := conn.IncrBy("a", 1)
+		assert.Nil(t, err, "first call must succeed")
+
+		_, err = conn.IncrBy("a", 1)
+		var redisErr redis.Error
+		assert.True(t, errors.As(err, &redisErr), "must fail with err")
+
+		num, err := conn.IncrBy("a", 1)
+		assert.Nil(t, err, "third call must succeed")
+		assert.Equal(t, 2, num, "failed call must not run")
+	})
+
+	t.Run("probability", func(t *testing.T) {
+		r := MockRedis().InjectFault(Fault{Command: "EXISTS", Probability: 0.5})
+		conn := r.Connection()
+
+		failed := 0
+		for i := 0; i < 1000; i++ {
+			if _, err := conn.Exists("a"); err != nil {
+				failed++
+			}
+		}
+
+		assert.InDelta(t, 500, failed, 100)
+		assert.Equal(t, failed, len(r.FiredFaults()))
+	})
+
+	t.Run("latency and timeout", func(t *testing.T) {
+		r := MockRedis().
+			InjectFault(Fault{Kind: FaultLatency, Command: "GET", Latency: 20 * time.Millisecond}).
+			InjectFault(Fault{Kind: FaultTimeout, Command: "EXISTS"})
+		conn := r.Connection()
+
+		start := time.Now()
+		_, _, err := conn.GetString("a")
+		assert.Nil(t, err, "latency must only delay")
+		assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+
+		_, err = conn.Exists("a")
+		assert.ErrorIs(t, err, ErrTimeout)
+	})
+
+	t.Run("drop mid-pipeline", func(t *testing.T) {
+		r := MockRedis().InjectFault(Fault{Kind: FaultDrop, Command: "INCRBY", Key: "b"})
+		conn := r.Connection()
+
+		pipe := conn.Pipeline()
+		pipe.IncrBy("a", 1)
+		pipe.IncrBy("b", 1)
+		pipe.IncrBy("c", 1)
+		err := pipe.Exec()
+
+		var pipelineErr *PipelineError
+		assert.True(t, errors.As(err, &pipelineErr), "must be a pipeline error")
+		assert.Equal(t, 1, pipelineErr.Index)
+		assert.ErrorIs(t, err, ErrConnection)
+
+		found, _ := conn.Exists("a")
+		assert.True(t, found, "commands before the drop must run")
+
+		found, _ = conn.Exists("c")
+		assert.False(t, found, "commands after the drop must not run")
+	})
+
+	t.Run("retried", func(t *testing.T) {
+		r := MockRedis().
+			With("a", "value", 0).
+			WithRetry(RetryPolicy{MaxAttempts: 2, Backoff: func(int) time.Duration { return 0 }}).
+			InjectFault(Fault{Kind: FaultDrop, Nth: 1})
+		conn := r.Connection()
+
+		value, _, err := conn.GetString("a")
+		assert.Nil(t, err, "a dropped connection must be retried")
+		assert.Equal(t, "value", value)
+
+		r.ClearFaults()
+		assert.Equal(t, 1, len(r.FiredFaults()), "clearing must keep the ones fired")
+	})
+}
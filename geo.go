@@ -0,0 +1,233 @@
+package redis
+
+import (
+	"errors"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+type GeoLocation struct {
+	Member    string
+	Longitude float64
+	Latitude  float64
+}
+
+// GeoResult is a member found by GeoSearch, Distance being in the unit of
+// the search
+type GeoResult struct {
+	Member    string
+	Distance  float64
+	Longitude float64
+	Latitude  float64
+}
+
+type GeoSearchOptions struct {
+	// the search is centered on Member if set, else on Longitude and Latitude
+	Member    string
+	Longitude float64
+	Latitude  float64
+
+	// the search is within Radius if set, else within a Width by Height box
+	Radius float64
+	Width  float64
+	Height float64
+
+	// Unit of distances: "m" by default, "km", "mi" or "ft"
+	Unit string
+
+	// Count limits the results to the nearest ones, 0 applies no limit
+	Count int
+	// Desc sorts the results farthest first
+	Desc bool
+}
+
+func (o GeoSearchOptions) unit() string {
+	if len(o.Unit) == 0 {
+		return "m"
+	}
+
+	return o.Unit
+}
+
+func (o GeoSearchOptions) args(key string) []interface{} {
+	args := []interface{}{key}
+
+	if len(o.Member) > 0 {
+		args = append(args, "FROMMEMBER", o.Member)
+	} else {
+		args = append(args, "FROMLONLAT", o.Longitude, o.Latitude)
+	}
+
+	if o.Radius > 0 {
+		args = append(args, "BYRADIUS", o.Radius, o.unit())
+	} else {
+		args = append(args, "BYBOX", o.Width, o.Height, o.unit())
+	}
+
+	if o.Desc {
+		args = append(args, "DESC")
+	} else {
+		args = append(args, "ASC")
+	}
+
+	if o.Count > 0 {
+		args = append(args, "COUNT", o.Count)
+	}
+
+	return append(args, "WITHCOORD", "WITHDIST")
+}
+
+// GeoAdd adds or updates locations in the sorted set at key and returns the
+// number of members added
+func (c *RedisConnectionImpl) GeoAdd(key string, locations ...GeoLocation) (int, error) {
+	return redis.Int(c.conn.Do("GEOADD", geoAddArgs(key, locations)...))
+}
+
+// GeoDist returns the distance between two members in unit, "m" by default,
+// not found if one of them is missing
+func (c *RedisConnectionImpl) GeoDist(key string, member1 string, member2 string, unit string) (float64, bool, error) {
+	return getFloat(c.conn.Do("GEODIST", geoDistArgs(key, member1, member2, unit)...))
+}
+
+// GeoSearch returns the members within a radius or a box, nearest first
+func (c *RedisConnectionImpl) GeoSearch(key string, options GeoSearchOptions) ([]GeoResult, error) {
+	return getGeoResults(c.conn.Do("GEOSEARCH", options.args(key)...))
+}
+
+func (p *PipelineImpl) GeoAdd(key string, locations ...GeoLocation) *GeoAddCmd {
+	cmd := GeoAddCmd{
+		key:       key,
+		locations: locations,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+func (p *PipelineImpl) GeoDist(key string, member1 string, member2 string, unit string) *GeoDistCmd {
+	cmd := GeoDistCmd{
+		key:     key,
+		member1: member1,
+		member2: member2,
+		unit:    unit,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+func (p *PipelineImpl) GeoSearch(key string, options GeoSearchOptions) *GeoSearchCmd {
+	cmd := GeoSearchCmd{
+		key:     key,
+		options: options,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+// GeoAddCmd value is the number of members added
+type GeoAddCmd struct {
+	key       string
+	locations []GeoLocation
+	value     int
+}
+
+func (g *GeoAddCmd) Value() int {
+	return g.value
+}
+
+type GeoDistCmd struct {
+	key     string
+	member1 string
+	member2 string
+	unit    string
+	value   float64
+	found   bool
+}
+
+func (g *GeoDistCmd) Value() float64 {
+	return g.value
+}
+
+func (g *GeoDistCmd) Found() bool {
+	return g.found
+}
+
+type GeoSearchCmd struct {
+	key     string
+	options GeoSearchOptions
+	value   []GeoResult
+}
+
+func (g *GeoSearchCmd) Value() []GeoResult {
+	return g.value
+}
+
+func geoAddArgs(key string, locations []GeoLocation) []interface{} {
+	args := make([]interface{}, 0, 3*len(locations)+1)
+	args = append(args, key)
+
+	for _, location := range locations {
+		args = append(args, location.Longitude, location.Latitude, location.Member)
+	}
+
+	return args
+}
+
+func geoDistArgs(key string, member1 string, member2 string, unit string) []interface{} {
+	if len(unit) == 0 {
+		unit = "m"
+	}
+
+	return []interface{}{key, member1, member2, unit}
+}
+
+// getGeoResults parses the WITHCOORD WITHDIST reply, each result being
+// [member, distance, [longitude, latitude]]
+func getGeoResults(value interface{}, err error) ([]GeoResult, error) {
+	values, err := redis.Values(value, err)
+
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]GeoResult, 0, len(values))
+
+	for _, value := range values {
+		fields, err := redis.Values(value, nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(fields) != 3 {
+			return nil, errors.New("unexpected geo search reply")
+		}
+
+		result := GeoResult{}
+
+		if result.Member, err = redis.String(fields[0], nil); err != nil {
+			return nil, err
+		}
+
+		if result.Distance, err = redis.Float64(fields[1], nil); err != nil {
+			return nil, err
+		}
+
+		coordinates, err := redis.Float64s(fields[2], nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(coordinates) != 2 {
+			return nil, errors.New("unexpected geo search coordinates")
+		}
+
+		result.Longitude, result.Latitude = coordinates[0], coordinates[1]
+		results = append(results, result)
+	}
+
+	return results, nil
+}
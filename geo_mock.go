@@ -0,0 +1,228 @@
+package redis
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// locations are stored as redis does, in a sorted set scored by their 52 bit
+// geohash, so that they read back with the same precision loss
+
+const (
+	geoMinLongitude = -180.0
+	geoMaxLongitude = 180.0
+	geoMinLatitude  = -85.05112878
+	geoMaxLatitude  = 85.05112878
+	geoStep         = 26
+	// geoEarthRadius in meters is the one used by redis
+	geoEarthRadius = 6372797.560856
+)
+
+// geoUnits are meters per unit
+var geoUnits = map[string]float64{
+	"m":  1,
+	"km": 1000,
+	"mi": 1609.34,
+	"ft": 0.3048,
+}
+
+// geoEncode interleaves the latitude cell in the even bits and the longitude
+// cell in the odd bits
+func geoEncode(longitude float64, latitude float64) float64 {
+	latCell := uint64((latitude - geoMinLatitude) / (geoMaxLatitude - geoMinLatitude) * (1 << geoStep))
+	lonCell := uint64((longitude - geoMinLongitude) / (geoMaxLongitude - geoMinLongitude) * (1 << geoStep))
+
+	hash := uint64(0)
+
+	for i := 0; i < geoStep; i++ {
+		hash |= (latCell >> uint(i) & 1) << uint(2*i)
+		hash |= (lonCell >> uint(i) & 1) << uint(2*i+1)
+	}
+
+	return float64(hash)
+}
+
+// geoDecode returns the center of the geohash cell
+func geoDecode(score float64) (float64, float64) {
+	hash := uint64(score)
+	latCell, lonCell := uint64(0), uint64(0)
+
+	for i := 0; i < geoStep; i++ {
+		latCell |= (hash >> uint(2*i) & 1) << uint(i)
+		lonCell |= (hash >> uint(2*i+1) & 1) << uint(i)
+	}
+
+	cell := func(value uint64, min float64, max float64) float64 {
+		low := min + float64(value)/(1<<geoStep)*(max-min)
+		high := min + float64(value+1)/(1<<geoStep)*(max-min)
+
+		return math.Max(min, math.Min(max, (low+high)/2))
+	}
+
+	return cell(lonCell, geoMinLongitude, geoMaxLongitude), cell(latCell, geoMinLatitude, geoMaxLatitude)
+}
+
+// geoDistance is the haversine distance in meters
+func geoDistance(lon1 float64, lat1 float64, lon2 float64, lat2 float64) float64 {
+	lat1r, lat2r := lat1*math.Pi/180, lat2*math.Pi/180
+	u := math.Sin((lat2r - lat1r) / 2)
+	v := math.Sin((lon2 - lon1) * math.Pi / 180 / 2)
+
+	return 2 * geoEarthRadius * math.Asin(math.Sqrt(u*u+math.Cos(lat1r)*math.Cos(lat2r)*v*v))
+}
+
+// geoRound rounds distances to 4 decimals as redis replies them
+func geoRound(distance float64) float64 {
+	return math.Round(distance*10000) / 10000
+}
+
+func geoUnit(unit string) (float64, error) {
+	if len(unit) == 0 {
+		return 1, nil
+	}
+
+	meters, ok := geoUnits[strings.ToLower(unit)]
+
+	if !ok {
+		return 0, mockError("ERR unsupported unit provided. please use M, KM, FT, MI")
+	}
+
+	return meters, nil
+}
+
+func (c *RedisConnectionMock) GeoAdd(key string, locations ...GeoLocation) (int, error) {
+	for _, location := range locations {
+		if location.Longitude < geoMinLongitude || location.Longitude > geoMaxLongitude ||
+			location.Latitude < geoMinLatitude || location.Latitude > geoMaxLatitude {
+			return 0, mockError("ERR invalid longitude,latitude pair")
+		}
+	}
+
+	members := make([]Z, 0, len(locations))
+
+	for _, location := range locations {
+		members = append(members, Z{Member: location.Member, Score: geoEncode(location.Longitude, location.Latitude)})
+	}
+
+	return c.ZAdd(key, members...)
+}
+
+func (c *RedisConnectionMock) GeoDist(key string, member1 string, member2 string, unit string) (float64, bool, error) {
+	meters, err := geoUnit(unit)
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	score1, found1, err := c.ZScore(key, member1)
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	score2, found2, err := c.ZScore(key, member2)
+
+	if err != nil || !found1 || !found2 {
+		return 0, false, err
+	}
+
+	lon1, lat1 := geoDecode(score1)
+	lon2, lat2 := geoDecode(score2)
+
+	return geoRound(geoDistance(lon1, lat1, lon2, lat2) / meters), true, nil
+}
+
+func (c *RedisConnectionMock) GeoSearch(key string, options GeoSearchOptions) ([]GeoResult, error) {
+	meters, err := geoUnit(options.Unit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	zs, err := c.ZRangeWithScores(key, ZRangeOptions{Start: 0, Stop: -1})
+
+	if err != nil {
+		return nil, err
+	}
+
+	lon, lat := options.Longitude, options.Latitude
+
+	if len(options.Member) > 0 {
+		score, found, err := c.ZScore(key, options.Member)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !found {
+			return nil, mockError("ERR could not decode requested zset member")
+		}
+
+		lon, lat = geoDecode(score)
+	}
+
+	results := []GeoResult{}
+
+	for _, z := range zs {
+		zLon, zLat := geoDecode(z.Score)
+		distance := geoDistance(lon, lat, zLon, zLat)
+
+		if options.Radius > 0 {
+			if distance > options.Radius*meters {
+				continue
+			}
+		} else if geoDistance(lon, lat, lon, zLat) > options.Height*meters/2 ||
+			geoDistance(lon, zLat, zLon, zLat) > options.Width*meters/2 {
+			// the box is checked along the meridian of the center, then along
+			// the parallel of the member
+			continue
+		}
+
+		results = append(results, GeoResult{
+			Member:    z.Member,
+			Distance:  distance / meters,
+			Longitude: zLon,
+			Latitude:  zLat,
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if options.Desc {
+			return results[i].Distance > results[j].Distance
+		}
+
+		return results[i].Distance < results[j].Distance
+	})
+
+	if options.Count > 0 && options.Count < len(results) {
+		results = results[:options.Count]
+	}
+
+	for i := range results {
+		results[i].Distance = geoRound(results[i].Distance)
+	}
+
+	return results, nil
+}
+
+func (p *PipelineMock) GeoAdd(key string, locations ...GeoLocation) *GeoAddCmd {
+	cmd := GeoAddCmd{key: key, locations: locations}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) GeoDist(key string, member1 string, member2 string, unit string) *GeoDistCmd {
+	cmd := GeoDistCmd{key: key, member1: member1, member2: member2, unit: unit}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) GeoSearch(key string, options GeoSearchOptions) *GeoSearchCmd {
+	cmd := GeoSearchCmd{key: key, options: options}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
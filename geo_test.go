@@ -0,0 +1,66 @@
+package redis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// expected values are the ones of the redis documentation examples
+
+func TestGeo(t *testing.T) {
+	conn := MockRedis().Connection()
+
+	num, err := conn.GeoAdd("Sicily",
+		GeoLocation{Member: "Palermo", Longitude: 13.361389, Latitude: 38.115556},
+		GeoLocation{Member: "Catania", Longitude: 15.087269, Latitude: 37.502669},
+	)
+	assert.Nil(t, err, "must succeed")
+	assert.Equal(t, 2, num)
+
+	distance, found, _ := conn.GeoDist("Sicily", "Palermo", "Catania", "")
+	assert.True(t, found, "must find both")
+	assert.Equal(t, 166274.1516, distance)
+
+	distance, _, _ = conn.GeoDist("Sicily", "Palermo", "Catania", "km")
+	assert.Equal(t, 166.2742, distance)
+
+	_, found, _ = conn.GeoDist("Sicily", "Palermo", "Rome", "km")
+	assert.False(t, found, "Rome is missing")
+
+	results, _ := conn.GeoSearch("Sicily", GeoSearchOptions{Longitude: 15, Latitude: 37, Radius: 200, Unit: "km"})
+	assert.Len(t, results, 2)
+	assert.Equal(t, "Catania", results[0].Member)
+	assert.Equal(t, 56.4413, results[0].Distance)
+	assert.InDelta(t, 15.08726745843887329, results[0].Longitude, 1e-9)
+	assert.InDelta(t, 37.50266842333162032, results[0].Latitude, 1e-9)
+	assert.Equal(t, 190.4424, results[1].Distance)
+
+	results, _ = conn.GeoSearch("Sicily", GeoSearchOptions{Member: "Palermo", Radius: 100, Unit: "km"})
+	assert.Equal(t, "Palermo", results[0].Member)
+	assert.Len(t, results, 1)
+
+	pipe := conn.Pipeline()
+	pipe.GeoAdd("Sicily",
+		GeoLocation{Member: "edge1", Longitude: 12.758489, Latitude: 38.788135},
+		GeoLocation{Member: "edge2", Longitude: 17.241510, Latitude: 38.788135},
+	)
+	box := pipe.GeoSearch("Sicily", GeoSearchOptions{Longitude: 15, Latitude: 37, Width: 400, Height: 400, Unit: "km", Desc: true, Count: 2})
+
+	assert.Nil(t, pipe.Exec(), "must succeed")
+	assert.Equal(t, []string{"edge1", "edge2"}, geoMembers(box.Value()))
+	assert.Equal(t, 279.7405, box.Value()[0].Distance)
+
+	results, _ = conn.GeoSearch("Sicily", GeoSearchOptions{Longitude: 15, Latitude: 37, Width: 200, Height: 200, Unit: "km"})
+	assert.Equal(t, []string{"Catania"}, geoMembers(results))
+}
+
+func geoMembers(results []GeoResult) []string {
+	members := []string{}
+
+	for _, result := range results {
+		members = append(members, result.Member)
+	}
+
+	return members
+}
@@ -0,0 +1,167 @@
+package redis
+
+import (
+	"fmt"
+	"sort"
+)
+
+// hashes have no typed commands, the mock handles HSET, HGET, HDEL and
+// HGETALL sent with Do
+
+// getHash returns the hash at key, empty if missing. Lock must be held.
+func (r *RedisMock) getHash(key string) (map[string]string, error) {
+	if r.failsOnGet[key] {
+		return nil, mockFailure("get")
+	}
+
+	obj := r.lookup(key)
+
+	if obj == nil {
+		return map[string]string{}, nil
+	}
+
+	hash, ok := obj.data.(map[string]string)
+
+	if !ok {
+		return nil, mockWrongType()
+	}
+
+	return hash, nil
+}
+
+// storeHash stores hash at key, keeping its ttl, and removes the key once
+// empty as redis does. Lock must be held.
+func (r *RedisMock) storeHash(key string, hash map[string]string) error {
+	if r.failsOnSet[key] {
+		return mockFailure("set")
+	}
+
+	if len(hash) == 0 {
+		delete(r.db, key)
+		return nil
+	}
+
+	if obj := r.lookup(key); obj != nil {
+		obj.data = hash
+		return nil
+	}
+
+	r.db[key] = &RedisMockObject{data: hash}
+	return nil
+}
+
+func mockHSet(conn *RedisConnectionMock, args []interface{}) (interface{}, error) {
+	if len(args) < 3 || len(args)%2 == 0 {
+		return nil, mockError("ERR wrong number of arguments for 'hset' command")
+	}
+
+	r := conn.redis
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := fmt.Sprint(args[0])
+	hash, err := r.getHash(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	num := int64(0)
+
+	for i := 1; i < len(args); i += 2 {
+		field := fmt.Sprint(args[i])
+
+		if _, ok := hash[field]; !ok {
+			num++
+		}
+
+		hash[field] = redisArg(args[i+1])
+	}
+
+	return num, r.storeHash(key, hash)
+}
+
+func mockHGet(conn *RedisConnectionMock, args []interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, mockError("ERR wrong number of arguments for 'hget' command")
+	}
+
+	r := conn.redis
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	hash, err := r.getHash(fmt.Sprint(args[0]))
+
+	if err != nil {
+		return nil, err
+	}
+
+	value, ok := hash[fmt.Sprint(args[1])]
+
+	if !ok {
+		return nil, nil
+	}
+
+	return []byte(value), nil
+}
+
+func mockHDel(conn *RedisConnectionMock, args []interface{}) (interface{}, error) {
+	if len(args) < 2 {
+		return nil, mockError("ERR wrong number of arguments for 'hdel' command")
+	}
+
+	r := conn.redis
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := fmt.Sprint(args[0])
+	hash, err := r.getHash(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	num := int64(0)
+
+	for _, field := range mockCommandKeys(args[1:]) {
+		if _, ok := hash[field]; ok {
+			delete(hash, field)
+			num++
+		}
+	}
+
+	return num, r.storeHash(key, hash)
+}
+
+// mockHGetAll replies fields and values in field order, so that tests are
+// reproducible
+func mockHGetAll(conn *RedisConnectionMock, args []interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, mockError("ERR wrong number of arguments for 'hgetall' command")
+	}
+
+	r := conn.redis
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	hash, err := r.getHash(fmt.Sprint(args[0]))
+
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]string, 0, len(hash))
+
+	for field := range hash {
+		fields = append(fields, field)
+	}
+
+	sort.Strings(fields)
+	reply := make([]interface{}, 0, 2*len(fields))
+
+	for _, field := range fields {
+		reply = append(reply, []byte(field), []byte(hash[field]))
+	}
+
+	return reply, nil
+}
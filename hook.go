@@ -0,0 +1,134 @@
+package redis
+
+import (
+	"errors"
+)
+
+// Command is a command as sent to redis, which hooks may rewrite or reply
+// themselves
+type Command struct {
+	Name string
+	Args []interface{}
+
+	reply   interface{}
+	err     error
+	replied bool
+}
+
+// Reply returns the reply of the command, once processed or replied by a
+// hook
+func (c *Command) Reply() (interface{}, error) {
+	return c.reply, c.err
+}
+
+// SetReply replies the command. Set by BeforeProcess, the command isn't sent
+// and the BeforeProcess of the next hooks aren't called.
+func (c *Command) SetReply(reply interface{}, err error) {
+	c.reply = reply
+	c.err = err
+	c.replied = true
+}
+
+// Hook is called around every command, BeforeProcess in the order hooks were
+// added and AfterProcess in reverse order. An error of BeforeProcess replies
+// the command with it. Pipelines call the pipeline methods instead, with all
+// their commands.
+type Hook interface {
+	BeforeProcess(cmd *Command) error
+	AfterProcess(cmd *Command)
+	BeforeProcessPipeline(cmds []*Command) error
+	AfterProcessPipeline(cmds []*Command)
+}
+
+// BaseHook implements Hook doing nothing, to be embedded by hooks only
+// implementing some of its methods
+type BaseHook struct{}
+
+func (BaseHook) BeforeProcess(cmd *Command) error {
+	return nil
+}
+
+func (BaseHook) AfterProcess(cmd *Command) {}
+
+func (BaseHook) BeforeProcessPipeline(cmds []*Command) error {
+	return nil
+}
+
+func (BaseHook) AfterProcessPipeline(cmds []*Command) {}
+
+// WithHooks calls hooks around every command but the pub/sub ones
+func WithHooks(hooks ...Hook) Option {
+	return func(o *redisOptions) {
+		o.hooks = append(o.hooks, hooks...)
+	}
+}
+
+// processHooks runs cmd with run unless a hook replied it
+func processHooks(hooks []Hook, cmd *Command, run func(cmd *Command)) {
+	i := 0
+
+	for ; i < len(hooks) && !cmd.replied; i++ {
+		if err := hooks[i].BeforeProcess(cmd); err != nil {
+			cmd.SetReply(nil, err)
+		}
+	}
+
+	if !cmd.replied {
+		run(cmd)
+	}
+
+	for i--; i >= 0; i-- {
+		hooks[i].AfterProcess(cmd)
+	}
+}
+
+// processPipelineHooks runs cmds with run, which must skip the commands
+// replied by hooks, unless a hook failed the pipeline
+func processPipelineHooks(hooks []Hook, cmds []*Command, run func(cmds []*Command)) error {
+	var err error
+	i := 0
+
+	for ; i < len(hooks) && err == nil; i++ {
+		err = hooks[i].BeforeProcessPipeline(cmds)
+	}
+
+	if err == nil {
+		run(cmds)
+	}
+
+	for i--; i >= 0; i-- {
+		hooks[i].AfterProcessPipeline(cmds)
+	}
+
+	return err
+}
+
+// commandConn records the commands sent by sendCmds
+type commandConn struct {
+	cmds []*Command
+}
+
+func (c *commandConn) Send(command string, args ...interface{}) error {
+	c.cmds = append(c.cmds, &Command{Name: command, Args: args})
+	return nil
+}
+
+func (c *commandConn) Close() error {
+	return nil
+}
+
+func (c *commandConn) Err() error {
+	return nil
+}
+
+func (c *commandConn) Do(command string, args ...interface{}) (interface{}, error) {
+	return nil, c.Send(command, args...)
+}
+
+func (c *commandConn) Flush() error {
+	return nil
+}
+
+func (c *commandConn) Receive() (interface{}, error) {
+	return nil, errors.New("commandConn doesn't receive")
+}
@@ -0,0 +1,504 @@
+package redis
+
+import (
+	"context"
+	"reflect"
+	"time"
+)
+
+// WithHook adds hooks called around the next commands of the mock but the
+// pub/sub ones, on every connection including the open ones and on every
+// attempt of retried commands. Commands rewritten by hooks run through Do,
+// so only the ones Do handles can be rewritten. Replies set by hooks are read
+// as redis replies.
+func (r *RedisMock) WithHook(hooks ...Hook) *RedisMock {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.hooks = append(r.hooks, hooks...)
+	return r
+}
+
+// replyConn replies the reply of cmd to receiveCmds
+type replyConn struct {
+	commandConn
+	reply *Command
+}
+
+func (c *replyConn) Receive() (interface{}, error) {
+	return c.reply.Reply()
+}
+
+// execHooked runs the commands with the hooks of the mock, retried as the
+// policy of the pipeline allows
+func (p *PipelineMock) execHooked() error {
+	return p.retry.do(context.Background(), idempotentCmds(p.cmds), p.execOnce, nil)
+}
+
+// execOnce runs the commands with the hooks of the mock, a pipeline of a
+// hooked connection calling the hooks of single commands
+func (p *PipelineMock) execOnce() error {
+	p.conn.redis.mu.Lock()
+	hooks, breaker := p.conn.redis.hooks, p.conn.redis.breaker
+	p.conn.redis.mu.Unlock()
+
+	pipeline := 0
+
+	if !p.single {
+		pipeline = p.conn.redis.nextPipeline()
+	}
+
+	cmds := make([]*Command, 0, len(p.cmds))
+
+	for _, cmd := range p.cmds {
+		recorder := &commandConn{}
+
+		if err := sendCmds(recorder, []interface{}{cmd}); err != nil {
+			return err
+		}
+
+		cmds = append(cmds, recorder.cmds...)
+	}
+
+	// sent are the commands as called, to run the ones rewritten by hooks
+	sent := make([]*Command, 0, len(cmds))
+
+	for _, cmd := range cmds {
+		sent = append(sent, &Command{Name: cmd.Name, Args: append([]interface{}{}, cmd.Args...)})
+	}
+
+	run := func(commands []*Command) {
+		var dropped error
+
+		for i, command := range commands {
+			if command.replied {
+				continue
+			}
+
+			if dropped != nil {
+				command.err = dropped
+				continue
+			}
+
+			p.conn.redis.mu.Lock()
+			p.conn.redis.record(p.conn.id, pipeline, command.Name, command.Args)
+			p.conn.redis.mu.Unlock()
+
+			drop := false
+			command.err = breaker.call(func() error {
+				if err := p.conn.redis.transientFailure(command.Name); err != nil {
+					return err
+				}
+
+				var err error
+
+				if drop, err = p.conn.redis.injectFault(command); err != nil {
+					return err
+				}
+
+				if command.rewritten(sent[i]) {
+					return p.execRewritten(command)
+				}
+
+				return p.execCmd(p.cmds[i])
+			})
+
+			if drop {
+				dropped = command.err
+			}
+		}
+	}
+
+	if p.single {
+		processHooks(hooks, cmds[0], func(cmd *Command) {
+			run([]*Command{cmd})
+		})
+	} else if err := processPipelineHooks(hooks, cmds, run); err != nil {
+		return err
+	}
+
+	var firstErr error
+
+	for i, cmd := range cmds {
+		err := cmd.err
+
+		if cmd.replied {
+			err = receiveCmd(&replyConn{reply: cmd}, p.cmds[i])
+		}
+
+		if err == nil || firstErr != nil {
+			continue
+		}
+
+		if p.single {
+			return err
+		}
+
+		firstErr = pipelineError(i, p.cmds[i], err)
+	}
+
+	return firstErr
+}
+
+// execRewritten runs cmd, rewritten by a hook, through Do, its reply being
+// read as the reply of the command called
+func (p *PipelineMock) execRewritten(cmd *Command) error {
+	reply, err := p.conn.Do(cmd.Name, cmd.Args...)
+
+	if err != nil {
+		return err
+	}
+
+	cmd.reply, cmd.replied = reply.Value(), true
+	return nil
+}
+
+// rewritten tells whether a hook changed the name or args of c, sent as sent
+func (c *Command) rewritten(sent *Command) bool {
+	if c.Name != sent.Name || len(c.Args) != len(sent.Args) {
+		return true
+	}
+
+	for i, arg := range c.Args {
+		if !reflect.DeepEqual(arg, sent.Args[i]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hookedConnectionMock runs every command as a pipeline of one, for the
+// hooks, transient failures, faults, retries and recording of the mock to
+// apply
+type hookedConnectionMock struct {
+	*RedisConnectionMock
+	retry RetryPolicy
+}
+
+// exec runs the command queued by add as a pipeline of one
+func (c *hookedConnectionMock) exec(add func(p Pipeline)) error {
+	p := &PipelineMock{conn: c.RedisConnectionMock, single: true, retry: c.retry}
+	add(p)
+
+	return p.Exec()
+}
+
+// single runs the command queued by add as a pipeline of one, returning it
+func single[C any](c *hookedConnectionMock, add func(p Pipeline) C) (C, error) {
+	var cmd C
+	err := c.exec(func(p Pipeline) { cmd = add(p) })
+
+	return cmd, err
+}
+
+func (c *hookedConnectionMock) Pipeline() Pipeline {
+	return &PipelineMock{conn: c.RedisConnectionMock, cmds: make([]interface{}, 0, 30), retry: c.retry}
+}
+
+func (c *hookedConnectionMock) WithRetry(policy RetryPolicy) RedisConnection {
+	return &hookedConnectionMock{RedisConnectionMock: c.RedisConnectionMock, retry: policy}
+}
+
+func (c *hookedConnectionMock) WithContext(ctx context.Context) RedisConnection {
+	return c
+}
+
+func (c *hookedConnectionMock) Send(channel string, data []byte) error {
+	_, err := c.Publish(channel, data)
+	return err
+}
+
+func (c *hookedConnectionMock) Exists(key string) (bool, error) {
+	cmd, err := single(c, func(p Pipeline) *ExistsCmd { return p.Exists(key) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) Type(key string) (string, error) {
+	cmd, err := single(c, func(p Pipeline) *TypeCmd { return p.Type(key) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) SetExpire(key string, ttl int) error {
+	return c.exec(func(p Pipeline) { p.SetExpire(key, ttl) })
+}
+
+func (c *hookedConnectionMock) GetExpire(key string) (int, error) {
+	cmd, err := single(c, func(p Pipeline) *GetExpireCmd { return p.GetExpire(key) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) Delete(keys ...string) (int, error) {
+	cmd, err := single(c, func(p Pipeline) *DeleteCmd { return p.Delete(keys...) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) Unlink(keys ...string) (int, error) {
+	cmd, err := single(c, func(p Pipeline) *DeleteCmd { return p.Unlink(keys...) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) GetString(key string) (string, bool, error) {
+	cmd, err := single(c, func(p Pipeline) *GetStringCmd { return p.GetString(key) })
+	return cmd.Value(), cmd.Found(), err
+}
+
+func (c *hookedConnectionMock) SetString(key string, src string, ttl int) error {
+	return c.exec(func(p Pipeline) { p.SetString(key, src, ttl) })
+}
+
+func (c *hookedConnectionMock) GetInt(key string) (int, bool, error) {
+	cmd, err := single(c, func(p Pipeline) *GetIntCmd { return p.GetInt(key) })
+	return cmd.Value(), cmd.Found(), err
+}
+
+func (c *hookedConnectionMock) SetInt(key string, value int, ttl int) error {
+	return c.exec(func(p Pipeline) { p.SetInt(key, value, ttl) })
+}
+
+func (c *hookedConnectionMock) IncrBy(key string, by int) (int, error) {
+	cmd, err := single(c, func(p Pipeline) *IncrByCmd { return p.IncrBy(key, by) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) LPush(key string, values ...string) (int, error) {
+	cmd, err := single(c, func(p Pipeline) *PushCmd { return p.LPush(key, values...) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) RPush(key string, values ...string) (int, error) {
+	cmd, err := single(c, func(p Pipeline) *PushCmd { return p.RPush(key, values...) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) LPop(key string) (string, bool, error) {
+	cmd, err := single(c, func(p Pipeline) *PopCmd { return p.LPop(key) })
+	return cmd.Value(), cmd.Found(), err
+}
+
+func (c *hookedConnectionMock) RPop(key string) (string, bool, error) {
+	cmd, err := single(c, func(p Pipeline) *PopCmd { return p.RPop(key) })
+	return cmd.Value(), cmd.Found(), err
+}
+
+func (c *hookedConnectionMock) LRange(key string, start int, stop int) ([]string, error) {
+	cmd, err := single(c, func(p Pipeline) *LRangeCmd { return p.LRange(key, start, stop) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) LTrim(key string, start int, stop int) error {
+	return c.exec(func(p Pipeline) { p.LTrim(key, start, stop) })
+}
+
+func (c *hookedConnectionMock) LLen(key string) (int, error) {
+	cmd, err := single(c, func(p Pipeline) *LLenCmd { return p.LLen(key) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) BLPop(timeout int, keys ...string) (string, string, bool, error) {
+	cmd, err := single(c, func(p Pipeline) *BlockingPopCmd { return p.BLPop(timeout, keys...) })
+	return cmd.Key(), cmd.Value(), cmd.Found(), err
+}
+
+func (c *hookedConnectionMock) BRPop(timeout int, keys ...string) (string, string, bool, error) {
+	cmd, err := single(c, func(p Pipeline) *BlockingPopCmd { return p.BRPop(timeout, keys...) })
+	return cmd.Key(), cmd.Value(), cmd.Found(), err
+}
+
+func (c *hookedConnectionMock) SAdd(key string, members ...string) (int, error) {
+	cmd, err := single(c, func(p Pipeline) *SAddCmd { return p.SAdd(key, members...) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) SRem(key string, members ...string) (int, error) {
+	cmd, err := single(c, func(p Pipeline) *SRemCmd { return p.SRem(key, members...) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) SIsMember(key string, member string) (bool, error) {
+	cmd, err := single(c, func(p Pipeline) *SIsMemberCmd { return p.SIsMember(key, member) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) SMembers(key string) ([]string, error) {
+	cmd, err := single(c, func(p Pipeline) *SetMembersCmd { return p.SMembers(key) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) SInter(keys ...string) ([]string, error) {
+	cmd, err := single(c, func(p Pipeline) *SetMembersCmd { return p.SInter(keys...) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) SUnion(keys ...string) ([]string, error) {
+	cmd, err := single(c, func(p Pipeline) *SetMembersCmd { return p.SUnion(keys...) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) SCard(key string) (int, error) {
+	cmd, err := single(c, func(p Pipeline) *SCardCmd { return p.SCard(key) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) ZAdd(key string, members ...Z) (int, error) {
+	cmd, err := single(c, func(p Pipeline) *ZAddCmd { return p.ZAdd(key, members...) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) ZIncrBy(key string, by float64, member string) (float64, error) {
+	cmd, err := single(c, func(p Pipeline) *ZIncrByCmd { return p.ZIncrBy(key, by, member) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) ZScore(key string, member string) (float64, bool, error) {
+	cmd, err := single(c, func(p Pipeline) *ZScoreCmd { return p.ZScore(key, member) })
+	return cmd.Value(), cmd.Found(), err
+}
+
+func (c *hookedConnectionMock) ZRange(key string, options ZRangeOptions) ([]string, error) {
+	cmd, err := single(c, func(p Pipeline) *ZRangeCmd { return p.ZRange(key, options) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) ZRangeWithScores(key string, options ZRangeOptions) ([]Z, error) {
+	cmd, err := single(c, func(p Pipeline) *ZRangeWithScoresCmd { return p.ZRangeWithScores(key, options) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) ZRank(key string, member string) (int, bool, error) {
+	cmd, err := single(c, func(p Pipeline) *ZRankCmd { return p.ZRank(key, member) })
+	return cmd.Value(), cmd.Found(), err
+}
+
+func (c *hookedConnectionMock) ZRevRank(key string, member string) (int, bool, error) {
+	cmd, err := single(c, func(p Pipeline) *ZRankCmd { return p.ZRevRank(key, member) })
+	return cmd.Value(), cmd.Found(), err
+}
+
+func (c *hookedConnectionMock) ZRem(key string, members ...string) (int, error) {
+	cmd, err := single(c, func(p Pipeline) *ZRemCmd { return p.ZRem(key, members...) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) ZRemRangeByScore(key string, min string, max string) (int, error) {
+	cmd, err := single(c, func(p Pipeline) *ZRemRangeByScoreCmd { return p.ZRemRangeByScore(key, min, max) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) ZCard(key string) (int, error) {
+	cmd, err := single(c, func(p Pipeline) *ZCardCmd { return p.ZCard(key) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) XAdd(stream string, values map[string]string) (string, error) {
+	cmd, err := single(c, func(p Pipeline) *XAddCmd { return p.XAdd(stream, values) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) XLen(stream string) (int, error) {
+	cmd, err := single(c, func(p Pipeline) *XLenCmd { return p.XLen(stream) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) XGroupCreate(stream string, group string, start string, mkStream bool) error {
+	return c.exec(func(p Pipeline) { p.XGroupCreate(stream, group, start, mkStream) })
+}
+
+func (c *hookedConnectionMock) XRead(options XReadOptions) ([]XStream, error) {
+	cmd, err := single(c, func(p Pipeline) *XReadCmd { return p.XRead(options) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) XReadGroup(group string, consumer string, options XReadOptions) ([]XStream, error) {
+	cmd, err := single(c, func(p Pipeline) *XReadCmd { return p.XReadGroup(group, consumer, options) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) XAck(stream string, group string, ids ...string) (int, error) {
+	cmd, err := single(c, func(p Pipeline) *XAckCmd { return p.XAck(stream, group, ids...) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) XPending(stream string, group string, options XPendingOptions) ([]XPendingEntry, error) {
+	cmd, err := single(c, func(p Pipeline) *XPendingCmd { return p.XPending(stream, group, options) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) XClaim(stream string, group string, consumer string, minIdle time.Duration, ids ...string) ([]XMessage, error) {
+	cmd, err := single(c, func(p Pipeline) *XClaimCmd { return p.XClaim(stream, group, consumer, minIdle, ids...) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) PFAdd(key string, elements ...string) (bool, error) {
+	cmd, err := single(c, func(p Pipeline) *PFAddCmd { return p.PFAdd(key, elements...) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) PFCount(keys ...string) (int, error) {
+	cmd, err := single(c, func(p Pipeline) *PFCountCmd { return p.PFCount(keys...) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) PFMerge(dest string, keys ...string) error {
+	return c.exec(func(p Pipeline) { p.PFMerge(dest, keys...) })
+}
+
+func (c *hookedConnectionMock) SetBit(key string, offset int, value bool) (bool, error) {
+	cmd, err := single(c, func(p Pipeline) *SetBitCmd { return p.SetBit(key, offset, value) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) GetBit(key string, offset int) (bool, error) {
+	cmd, err := single(c, func(p Pipeline) *GetBitCmd { return p.GetBit(key, offset) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) BitCount(key string) (int, error) {
+	cmd, err := single(c, func(p Pipeline) *BitCountCmd { return p.BitCount(key) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) BitOp(op string, dest string, keys ...string) (int, error) {
+	cmd, err := single(c, func(p Pipeline) *BitOpCmd { return p.BitOp(op, dest, keys...) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) BitField(key string, ops ...BitFieldOp) ([]BitFieldValue, error) {
+	cmd, err := single(c, func(p Pipeline) *BitFieldCmd { return p.BitField(key, ops...) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) GeoAdd(key string, locations ...GeoLocation) (int, error) {
+	cmd, err := single(c, func(p Pipeline) *GeoAddCmd { return p.GeoAdd(key, locations...) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) GeoDist(key string, member1 string, member2 string, unit string) (float64, bool, error) {
+	cmd, err := single(c, func(p Pipeline) *GeoDistCmd { return p.GeoDist(key, member1, member2, unit) })
+	return cmd.Value(), cmd.Found(), err
+}
+
+func (c *hookedConnectionMock) GeoSearch(key string, options GeoSearchOptions) ([]GeoResult, error) {
+	cmd, err := single(c, func(p Pipeline) *GeoSearchCmd { return p.GeoSearch(key, options) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) Time() (time.Time, error) {
+	cmd, err := single(c, func(p Pipeline) *TimeCmd { return p.Time() })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) Eval(script *Script, keys []string, args ...interface{}) (interface{}, error) {
+	cmd, err := single(c, func(p Pipeline) *EvalCmd { return p.Eval(script, keys, args...) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) Do(command string, args ...interface{}) (Reply, error) {
+	cmd, err := single(c, func(p Pipeline) *DoCmd { return p.Do(command, args...) })
+	return cmd.Value(), err
+}
+
+func (c *hookedConnectionMock) Publish(channel string, data []byte) (int, error) {
+	cmd, err := single(c, func(p Pipeline) *PublishCmd { return p.Publish(channel, data) })
+	return cmd.Value(), err
+}
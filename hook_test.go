@@ -0,0 +1,187 @@
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+// echoDoConn is an echoConn also replying to Do with the first arg
+type echoDoConn struct {
+	echoConn
+}
+
+func (c *echoDoConn) Do(command string, args ...interface{}) (interface{}, error) {
+	if len(command) == 0 {
+		return nil, nil
+	}
+
+	return []byte(fmt.Sprint(args[0])), nil
+}
+
+// logHook logs its calls with its name
+type logHook struct {
+	name string
+	log  *[]string
+}
+
+func (h logHook) BeforeProcess(cmd *Command) error {
+	*h.log = append(*h.log, "before "+h.name+" "+cmd.Name)
+	return nil
+}
+
+func (h logHook) AfterProcess(cmd *Command) {
+	*h.log = append(*h.log, "after "+h.name+" "+cmd.Name)
+}
+
+func (h logHook) BeforeProcessPipeline(cmds []*Command) error {
+	*h.log = append(*h.log, fmt.Sprintf("before %s pipeline of %d", h.name, len(cmds)))
+	return nil
+}
+
+func (h logHook) AfterProcessPipeline(cmds []*Command) {
+	*h.log = append(*h.log, fmt.Sprintf("after %s pipeline of %d", h.name, len(cmds)))
+}
+
+// prefixHook prefixes the first arg of commands
+type prefixHook struct {
+	BaseHook
+	prefix string
+}
+
+func (h prefixHook) BeforeProcess(cmd *Command) error {
+	cmd.Args[0] = h.prefix + fmt.Sprint(cmd.Args[0])
+	return nil
+}
+
+func (h prefixHook) BeforeProcessPipeline(cmds []*Command) error {
+	for _, cmd := range cmds {
+		h.BeforeProcess(cmd)
+	}
+
+	return nil
+}
+
+// cacheHook replies GET cached itself and refuses DEL
+type cacheHook struct {
+	BaseHook
+}
+
+func (h cacheHook) BeforeProcess(cmd *Command) error {
+	if cmd.Name == "DEL" {
+		return errors.New("DEL is disabled")
+	}
+
+	if cmd.Name == "GET" && fmt.Sprint(cmd.Args[0]) == "cached" {
+		cmd.SetReply([]byte("hit"), nil)
+	}
+
+	return nil
+}
+
+func (h cacheHook) BeforeProcessPipeline(cmds []*Command) error {
+	for _, cmd := range cmds {
+		if err := h.BeforeProcess(cmd); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func TestHooks(t *testing.T) {
+	log := []string{}
+	flushes := []int{}
+	dial := func() (redis.Conn, error) {
+		return &echoDoConn{echoConn{mu: &sync.Mutex{}, flushes: &flushes}}, nil
+	}
+
+	options := newRedisOptions([]Option{WithHooks(logHook{"a", &log}, cacheHook{}, prefixHook{prefix: "app:"}, logHook{"b", &log})})
+	r := &RedisImpl{pool: &redis.Pool{Dial: dial}, options: options}
+	conn := r.Connection()
+	defer conn.Close()
+
+	value, _, err := conn.GetString("key")
+	assert.Nil(t, err, "must succeed")
+	assert.Equal(t, "app:key", value, "command must be rewritten")
+	assert.Equal(t, []string{"before a GET", "before b GET", "after b GET", "after a GET"}, log)
+
+	log = log[:0]
+	value, _, _ = conn.GetString("cached")
+	assert.Equal(t, "hit", value)
+	assert.Equal(t, []string{"before a GET", "after a GET"}, log, "next hooks must be short-circuited")
+
+	_, err = conn.Delete("key")
+	assert.Equal(t, "DEL is disabled", err.Error())
+
+	log = log[:0]
+	pipe := conn.Pipeline()
+	first := pipe.GetString("first")
+	cached := pipe.GetString("cached")
+	assert.Nil(t, pipe.Exec(), "must succeed")
+	assert.Equal(t, "app:first", first.Value())
+	assert.Equal(t, "hit", cached.Value())
+	assert.Equal(t, []int{1}, flushes, "replied commands must not be sent")
+	assert.Equal(t, []string{"before a pipeline of 2", "before b pipeline of 2", "after b pipeline of 2", "after a pipeline of 2"}, log)
+
+	pipe = conn.Pipeline()
+	pipe.Delete("key")
+	assert.Equal(t, "DEL is disabled", pipe.Exec().Error())
+}
+
+func TestMockHooks(t *testing.T) {
+	log := []string{}
+	r := MockRedis().WithHook(logHook{"a", &log}, cacheHook{})
+	conn := r.Connection()
+
+	conn.SetString("key", "value", 0)
+	value, _, _ := conn.GetString("key")
+	assert.Equal(t, "value", value)
+
+	value, found, _ := conn.GetString("cached")
+	assert.True(t, found, "hooks must reply")
+	assert.Equal(t, "hit", value)
+
+	_, err := conn.Delete("key")
+	assert.Equal(t, "DEL is disabled", err.Error())
+	assert.Equal(t, 1, r.GetNumKeys())
+
+	pipe := conn.Pipeline()
+	pipe.Exists("key")
+	pipe.IncrBy("n", 2)
+	assert.Nil(t, pipe.Exec(), "must succeed")
+
+	assert.Equal(t, []string{
+		"before a SETEX", "after a SETEX",
+		"before a GET", "after a GET",
+		"before a GET", "after a GET",
+		"before a DEL", "after a DEL",
+		"before a pipeline of 2", "after a pipeline of 2",
+	}, log)
+}
+
+func TestMockHooksRewrite(t *testing.T) {
+	r := MockRedis().With("app:key", "value", 0).WithHook(prefixHook{prefix: "app:"})
+	conn := r.Connection()
+
+	value, found, err := conn.GetString("key")
+	assert.Nil(t, err, "must succeed")
+	assert.True(t, found, "rewritten command must run")
+	assert.Equal(t, "value", value)
+
+	pipe := conn.Pipeline()
+	exists := pipe.Exists("key")
+	missing := pipe.GetString("missing")
+	assert.Nil(t, pipe.Exec(), "must succeed")
+	assert.True(t, exists.Value(), "rewritten pipeline commands must run")
+	assert.False(t, missing.Found())
+
+	_, err = conn.IncrBy("key", 1)
+	assert.ErrorIs(t, err, ErrUnsupportedCommand, "commands Do doesn't handle can't be rewritten")
+
+	r.ExpectCommand(t, "GET", "app:key")
+}
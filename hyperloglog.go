@@ -0,0 +1,77 @@
+package redis
+
+import (
+	"github.com/gomodule/redigo/redis"
+)
+
+// PFAdd adds elements to the HyperLogLog at key and returns whether its
+// estimated cardinality changed
+func (c *RedisConnectionImpl) PFAdd(key string, elements ...string) (bool, error) {
+	return redis.Bool(c.conn.Do("PFADD", keyArgs(key, elements)...))
+}
+
+// PFCount returns the estimated cardinality of the union of the HyperLogLogs
+// at keys
+func (c *RedisConnectionImpl) PFCount(keys ...string) (int, error) {
+	return redis.Int(c.conn.Do("PFCOUNT", stringArgs(keys)...))
+}
+
+// PFMerge merges the HyperLogLogs at keys into dest
+func (c *RedisConnectionImpl) PFMerge(dest string, keys ...string) error {
+	_, err := c.conn.Do("PFMERGE", keyArgs(dest, keys)...)
+	return err
+}
+
+func (p *PipelineImpl) PFAdd(key string, elements ...string) *PFAddCmd {
+	cmd := PFAddCmd{
+		key:      key,
+		elements: elements,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+func (p *PipelineImpl) PFCount(keys ...string) *PFCountCmd {
+	cmd := PFCountCmd{
+		keys: keys,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+func (p *PipelineImpl) PFMerge(dest string, keys ...string) *PFMergeCmd {
+	cmd := PFMergeCmd{
+		dest: dest,
+		keys: keys,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+// PFAddCmd value tells whether the estimated cardinality changed
+type PFAddCmd struct {
+	key      string
+	elements []string
+	value    bool
+}
+
+func (p *PFAddCmd) Value() bool {
+	return p.value
+}
+
+type PFCountCmd struct {
+	keys  []string
+	value int
+}
+
+func (p *PFCountCmd) Value() int {
+	return p.value
+}
+
+type PFMergeCmd struct {
+	dest string
+	keys []string
+}
@@ -0,0 +1,126 @@
+package redis
+
+import ()
+
+// mockHLL counts exactly the elements added, so that tests are deterministic
+type mockHLL map[string]struct{}
+
+// getHLL returns the HyperLogLog at key, empty if missing. Lock must be held.
+func (r *RedisMock) getHLL(key string) (mockHLL, error) {
+	if r.failsOnGet[key] {
+		return nil, mockFailure("get")
+	}
+
+	obj := r.lookup(key)
+
+	if obj == nil {
+		return mockHLL{}, nil
+	}
+
+	hll, ok := obj.data.(mockHLL)
+
+	if !ok {
+		return nil, mockError("WRONGTYPE Key is not a valid HyperLogLog string value.")
+	}
+
+	return hll, nil
+}
+
+// storeHLL stores hll at key, keeping its ttl. Lock must be held.
+func (r *RedisMock) storeHLL(key string, hll mockHLL) error {
+	if r.failsOnSet[key] {
+		return mockFailure("set")
+	}
+
+	if obj := r.lookup(key); obj != nil {
+		obj.data = hll
+		return nil
+	}
+
+	r.db[key] = &RedisMockObject{data: hll}
+	return nil
+}
+
+func (c *RedisConnectionMock) PFAdd(key string, elements ...string) (bool, error) {
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
+	hll, err := c.redis.getHLL(key)
+
+	if err != nil {
+		return false, err
+	}
+
+	// like redis, creating an empty HyperLogLog counts as a change
+	changed := c.redis.lookup(key) == nil
+
+	for _, element := range elements {
+		if _, ok := hll[element]; !ok {
+			hll[element] = struct{}{}
+			changed = true
+		}
+	}
+
+	return changed, c.redis.storeHLL(key, hll)
+}
+
+func (c *RedisConnectionMock) PFCount(keys ...string) (int, error) {
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
+	union, err := c.redis.hllUnion(keys)
+	return len(union), err
+}
+
+func (c *RedisConnectionMock) PFMerge(dest string, keys ...string) error {
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
+	union, err := c.redis.hllUnion(append([]string{dest}, keys...))
+
+	if err != nil {
+		return err
+	}
+
+	return c.redis.storeHLL(dest, union)
+}
+
+// hllUnion returns the union of the HyperLogLogs at keys. Lock must be held.
+func (r *RedisMock) hllUnion(keys []string) (mockHLL, error) {
+	union := mockHLL{}
+
+	for _, key := range keys {
+		hll, err := r.getHLL(key)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for element := range hll {
+			union[element] = struct{}{}
+		}
+	}
+
+	return union, nil
+}
+
+func (p *PipelineMock) PFAdd(key string, elements ...string) *PFAddCmd {
+	cmd := PFAddCmd{key: key, elements: elements}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) PFCount(keys ...string) *PFCountCmd {
+	cmd := PFCountCmd{keys: keys}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) PFMerge(dest string, keys ...string) *PFMergeCmd {
+	cmd := PFMergeCmd{dest: dest, keys: keys}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
@@ -0,0 +1,33 @@
+package redis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHyperLogLog(t *testing.T) {
+	conn := MockRedis().Connection()
+
+	changed, err := conn.PFAdd("visitors.mon", "alice", "bob")
+	assert.Nil(t, err, "must succeed")
+	assert.True(t, changed, "must add")
+
+	changed, _ = conn.PFAdd("visitors.mon", "alice")
+	assert.False(t, changed, "already counted")
+
+	conn.PFAdd("visitors.tue", "bob", "carol")
+
+	count, _ := conn.PFCount("visitors.mon", "visitors.tue")
+	assert.Equal(t, 3, count, "must count the union")
+
+	pipe := conn.Pipeline()
+	pipe.PFMerge("visitors.week", "visitors.mon", "visitors.tue")
+	week := pipe.PFCount("visitors.week")
+
+	assert.Nil(t, pipe.Exec(), "must succeed")
+	assert.Equal(t, 3, week.Value())
+
+	count, _ = conn.PFCount("missing")
+	assert.Equal(t, 0, count)
+}
@@ -0,0 +1,262 @@
+package redis
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// instrumentedConn calls the hooks around every command, and traces,
+// measures and logs the commands sent with Do, as configured, the commands
+// of a pipeline being instrumented as a whole by PipelineImpl.Exec. Spans are
+// children of the span in the ctx of DoContext, roots for Do.
+type instrumentedConn struct {
+	redis.Conn
+	options *redisOptions
+	// pool is the pool conn comes from, nil if none
+	pool *redis.Pool
+
+	// pending are the commands sent until the next Flush, replies the ones
+	// flushed and not received yet
+	pending []*Command
+	replies []*Command
+}
+
+// instrument wraps conn from pool
+func (r *RedisImpl) instrument(conn redis.Conn) redis.Conn {
+	return &instrumentedConn{Conn: conn, options: r.options, pool: r.pool}
+}
+
+// instrumented returns the instrumentedConn of conn, wrapping it if needed
+func instrumented(conn redis.Conn) *instrumentedConn {
+	switch c := conn.(type) {
+	case *instrumentedConn:
+		return c
+	case *retryConn:
+		return c.instrumentedConn
+	case *contextConn:
+		return instrumented(c.contextDoer)
+	}
+
+	return &instrumentedConn{Conn: conn, options: newRedisOptions(nil)}
+}
+
+// uninstrumented returns the conn wrapped by conn, if any
+func uninstrumented(conn redis.Conn) redis.Conn {
+	switch c := conn.(type) {
+	case *instrumentedConn:
+		return c.Conn
+	case *retryConn:
+		return c.Conn
+	case *contextConn:
+		return uninstrumented(c.contextDoer)
+	}
+
+	return conn
+}
+
+// contextDoer is a conn sending commands in a context, as instrumentedConn
+// and retryConn do
+type contextDoer interface {
+	redis.Conn
+	DoContext(ctx context.Context, command string, args ...interface{}) (interface{}, error)
+}
+
+// contextConn sends the commands of Do in ctx
+type contextConn struct {
+	contextDoer
+	ctx context.Context
+}
+
+func (c *contextConn) Do(command string, args ...interface{}) (interface{}, error) {
+	return c.contextDoer.DoContext(c.ctx, command, args...)
+}
+
+// withContext returns conn sending its commands in ctx
+func withContext(conn redis.Conn, ctx context.Context) redis.Conn {
+	if c, ok := conn.(*contextConn); ok {
+		conn = c.contextDoer
+	}
+
+	doer, ok := conn.(contextDoer)
+
+	if !ok {
+		doer = instrumented(conn)
+	}
+
+	return &contextConn{contextDoer: doer, ctx: ctx}
+}
+
+// contextOf returns the ctx conn sends its commands in
+func contextOf(conn redis.Conn) context.Context {
+	if c, ok := conn.(*contextConn); ok {
+		return c.ctx
+	}
+
+	return context.Background()
+}
+
+// reconnect replaces the conn by a new one of the pool, dropping the
+// commands not received
+func (c *instrumentedConn) reconnect() {
+	if c.pool == nil {
+		return
+	}
+
+	c.Conn.Close()
+	c.Conn = getConn(c.pool, c.options.breaker)
+	c.pending = nil
+	c.replies = nil
+}
+
+func (c *instrumentedConn) Do(command string, args ...interface{}) (interface{}, error) {
+	return c.DoContext(context.Background(), command, args...)
+}
+
+// DoContext is Do, its span being a child of the span in ctx
+func (c *instrumentedConn) DoContext(ctx context.Context, command string, args ...interface{}) (interface{}, error) {
+	// redigo flushes and receives pending replies with an empty command
+	if len(command) == 0 || len(c.options.hooks) == 0 {
+		return c.do(ctx, command, args...)
+	}
+
+	// hooks get a copy of args, so that rewrites don't pile up on retries
+	cmd := &Command{Name: command, Args: append([]interface{}{}, args...)}
+	processHooks(c.options.hooks, cmd, func(cmd *Command) {
+		cmd.SetReply(c.do(ctx, cmd.Name, cmd.Args...))
+	})
+
+	return cmd.Reply()
+}
+
+func (c *instrumentedConn) Send(command string, args ...interface{}) error {
+	if len(c.options.hooks) == 0 {
+		return c.Conn.Send(command, args...)
+	}
+
+	c.pending = append(c.pending, &Command{Name: command, Args: append([]interface{}{}, args...)})
+	return nil
+}
+
+// Flush calls the pipeline hooks around the pending commands, which are sent
+// and received at once, their replies being returned by Receive
+func (c *instrumentedConn) Flush() error {
+	if len(c.pending) == 0 {
+		return c.Conn.Flush()
+	}
+
+	cmds := c.pending
+	c.pending = nil
+
+	if err := processPipelineHooks(c.options.hooks, cmds, c.sendPipeline); err != nil {
+		return err
+	}
+
+	c.replies = append(c.replies, cmds...)
+	return nil
+}
+
+func (c *instrumentedConn) Receive() (interface{}, error) {
+	if len(c.replies) == 0 {
+		reply, err := c.Conn.Receive()
+		return reply, commandError(err)
+	}
+
+	cmd := c.replies[0]
+	c.replies = c.replies[1:]
+
+	return cmd.Reply()
+}
+
+// sendPipeline sends and receives the cmds not replied by hooks
+func (c *instrumentedConn) sendPipeline(cmds []*Command) {
+	sent := make([]*Command, 0, len(cmds))
+	fail := func(err error) {
+		for _, cmd := range cmds {
+			if !cmd.replied {
+				cmd.SetReply(nil, err)
+			}
+		}
+	}
+
+	for _, cmd := range cmds {
+		if cmd.replied {
+			continue
+		}
+
+		if err := c.Conn.Send(cmd.Name, cmd.Args...); err != nil {
+			fail(commandError(err))
+			return
+		}
+
+		sent = append(sent, cmd)
+	}
+
+	if err := c.Conn.Flush(); err != nil {
+		fail(commandError(err))
+		return
+	}
+
+	for _, cmd := range sent {
+		reply, err := c.Conn.Receive()
+		cmd.SetReply(reply, commandError(err))
+	}
+}
+
+// do traces and measures command
+func (c *instrumentedConn) do(ctx context.Context, command string, args ...interface{}) (interface{}, error) {
+	if len(command) == 0 {
+		return c.Conn.Do(command, args...)
+	}
+
+	operation := strings.ToUpper(command)
+	span := c.startSpan(ctx, operation,
+		attribute.String("db.operation", operation),
+		attribute.String("db.statement", sanitizeStatement(operation, args, nil)),
+	)
+	start := time.Now()
+
+	var reply interface{}
+	err := c.options.breaker.call(func() error {
+		var err error
+		reply, err = c.Conn.Do(command, args...)
+
+		return commandError(err)
+	})
+	duration := time.Since(start)
+
+	if c.options.metrics != nil {
+		c.options.metrics.ObserveCommand(operation, duration, errorType(err))
+	}
+
+	c.options.logCommand(operation, args, duration, err)
+
+	endSpan(span, err)
+	return reply, err
+}
+
+// instrumentPipeline starts instrumenting a pipeline of size commands on
+// conn, in the ctx of conn, and returns the func ending it
+func instrumentPipeline(conn redis.Conn, size int) func(err error) {
+	c := instrumented(conn)
+	span := c.startSpan(contextOf(conn), "PIPELINE",
+		attribute.String("db.operation", "PIPELINE"),
+		attribute.Int("db.operation.batch.size", size),
+	)
+	start := time.Now()
+
+	return func(err error) {
+		duration := time.Since(start)
+
+		if c.options.metrics != nil {
+			c.options.metrics.ObservePipeline(size, duration, errorType(err))
+		}
+
+		c.options.logPipeline(size, duration, err)
+
+		endSpan(span, err)
+	}
+}
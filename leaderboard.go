@@ -0,0 +1,108 @@
+package redis
+
+// LeaderboardEntry is a member with its score and its rank, 1 being the
+// highest score
+type LeaderboardEntry struct {
+	Member string
+	Score  float64
+	Rank   int
+}
+
+// Leaderboard ranks members by descending score in the sorted set at key
+type Leaderboard struct {
+	key string
+}
+
+func NewLeaderboard(key string) *Leaderboard {
+	return &Leaderboard{
+		key: key,
+	}
+}
+
+func (l *Leaderboard) Set(member string, score float64, conn RedisConnection) error {
+	_, err := conn.ZAdd(l.key, Z{Member: member, Score: score})
+	return err
+}
+
+// Incr adds by to the score of member and returns its new score
+func (l *Leaderboard) Incr(member string, by float64, conn RedisConnection) (float64, error) {
+	return conn.ZIncrBy(l.key, by, member)
+}
+
+func (l *Leaderboard) Remove(member string, conn RedisConnection) error {
+	_, err := conn.ZRem(l.key, member)
+	return err
+}
+
+// Top returns the n highest ranked entries
+func (l *Leaderboard) Top(n int, conn RedisConnection) ([]LeaderboardEntry, error) {
+	if n <= 0 {
+		return []LeaderboardEntry{}, nil
+	}
+
+	return l.entries(0, n-1, conn)
+}
+
+// RankOf returns the entry of member, not found if it isn't ranked
+func (l *Leaderboard) RankOf(member string, conn RedisConnection) (LeaderboardEntry, bool, error) {
+	pipe := conn.Pipeline()
+	rank := pipe.ZRevRank(l.key, member)
+	score := pipe.ZScore(l.key, member)
+
+	if err := pipe.Exec(); err != nil {
+		return LeaderboardEntry{}, false, err
+	}
+
+	if !rank.Found() || !score.Found() {
+		return LeaderboardEntry{}, false, nil
+	}
+
+	return LeaderboardEntry{
+		Member: member,
+		Score:  score.Value(),
+		Rank:   rank.Value() + 1,
+	}, true, nil
+}
+
+// AroundMe returns the entry of member surrounded by up to n entries ranked
+// above and n entries ranked below, empty if member isn't ranked
+func (l *Leaderboard) AroundMe(member string, n int, conn RedisConnection) ([]LeaderboardEntry, error) {
+	rank, found, err := conn.ZRevRank(l.key, member)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return []LeaderboardEntry{}, nil
+	}
+
+	start := rank - n
+
+	if start < 0 {
+		start = 0
+	}
+
+	return l.entries(start, rank+n, conn)
+}
+
+// entries returns the entries ranked from start to stop, 0 based
+func (l *Leaderboard) entries(start int, stop int, conn RedisConnection) ([]LeaderboardEntry, error) {
+	zs, err := conn.ZRangeWithScores(l.key, ZRangeOptions{Start: start, Stop: stop, Rev: true})
+
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]LeaderboardEntry, 0, len(zs))
+
+	for i, z := range zs {
+		entries = append(entries, LeaderboardEntry{
+			Member: z.Member,
+			Score:  z.Score,
+			Rank:   start + i + 1,
+		})
+	}
+
+	return entries, nil
+}
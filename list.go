@@ -0,0 +1,254 @@
+package redis
+
+import (
+	"errors"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+func (c *RedisConnectionImpl) LPush(key string, values ...string) (int, error) {
+	return redis.Int(c.conn.Do("LPUSH", keyArgs(key, values)...))
+}
+
+func (c *RedisConnectionImpl) RPush(key string, values ...string) (int, error) {
+	return redis.Int(c.conn.Do("RPUSH", keyArgs(key, values)...))
+}
+
+func (c *RedisConnectionImpl) LPop(key string) (string, bool, error) {
+	return getString(c.conn.Do("LPOP", key))
+}
+
+func (c *RedisConnectionImpl) RPop(key string) (string, bool, error) {
+	return getString(c.conn.Do("RPOP", key))
+}
+
+func (c *RedisConnectionImpl) LRange(key string, start int, stop int) ([]string, error) {
+	return redis.Strings(c.conn.Do("LRANGE", key, start, stop))
+}
+
+func (c *RedisConnectionImpl) LTrim(key string, start int, stop int) error {
+	_, err := c.conn.Do("LTRIM", key, start, stop)
+	return err
+}
+
+func (c *RedisConnectionImpl) LLen(key string) (int, error) {
+	return redis.Int(c.conn.Do("LLEN", key))
+}
+
+func (c *RedisConnectionImpl) BLPop(timeout int, keys ...string) (string, string, bool, error) {
+	return getBlockingPop(c.conn.Do("BLPOP", blockingArgs(keys, timeout)...))
+}
+
+func (c *RedisConnectionImpl) BRPop(timeout int, keys ...string) (string, string, bool, error) {
+	return getBlockingPop(c.conn.Do("BRPOP", blockingArgs(keys, timeout)...))
+}
+
+func (p *PipelineImpl) LPush(key string, values ...string) *PushCmd {
+	cmd := PushCmd{
+		key:    key,
+		values: values,
+		left:   true,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+func (p *PipelineImpl) RPush(key string, values ...string) *PushCmd {
+	cmd := PushCmd{
+		key:    key,
+		values: values,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+func (p *PipelineImpl) LPop(key string) *PopCmd {
+	cmd := PopCmd{
+		key:  key,
+		left: true,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+func (p *PipelineImpl) RPop(key string) *PopCmd {
+	cmd := PopCmd{
+		key: key,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+func (p *PipelineImpl) LRange(key string, start int, stop int) *LRangeCmd {
+	cmd := LRangeCmd{
+		key:   key,
+		start: start,
+		stop:  stop,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+func (p *PipelineImpl) LTrim(key string, start int, stop int) {
+	cmd := LTrimCmd{
+		key:   key,
+		start: start,
+		stop:  stop,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+}
+
+func (p *PipelineImpl) LLen(key string) *LLenCmd {
+	cmd := LLenCmd{
+		key: key,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+// BLPop holds the connection, and so the whole pipeline, until an item is
+// popped or the timeout is over
+func (p *PipelineImpl) BLPop(timeout int, keys ...string) *BlockingPopCmd {
+	cmd := BlockingPopCmd{
+		timeout: timeout,
+		keys:    keys,
+		left:    true,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+func (p *PipelineImpl) BRPop(timeout int, keys ...string) *BlockingPopCmd {
+	cmd := BlockingPopCmd{
+		timeout: timeout,
+		keys:    keys,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+// PushCmd is a LPUSH or RPUSH, its value is the list length after the push
+type PushCmd struct {
+	key    string
+	values []string
+	left   bool
+	value  int
+}
+
+func (p *PushCmd) Value() int {
+	return p.value
+}
+
+// PopCmd is a LPOP or RPOP
+type PopCmd struct {
+	key   string
+	left  bool
+	value string
+	found bool
+}
+
+func (p *PopCmd) Value() string {
+	return p.value
+}
+
+func (p *PopCmd) Found() bool {
+	return p.found
+}
+
+type LRangeCmd struct {
+	key   string
+	start int
+	stop  int
+	value []string
+}
+
+func (l *LRangeCmd) Value() []string {
+	return l.value
+}
+
+type LTrimCmd struct {
+	key   string
+	start int
+	stop  int
+}
+
+type LLenCmd struct {
+	key   string
+	value int
+}
+
+func (l *LLenCmd) Value() int {
+	return l.value
+}
+
+// BlockingPopCmd is a BLPOP or BRPOP, Key being the key popped from
+type BlockingPopCmd struct {
+	timeout int
+	keys    []string
+	left    bool
+	key     string
+	value   string
+	found   bool
+}
+
+func (b *BlockingPopCmd) Key() string {
+	return b.key
+}
+
+func (b *BlockingPopCmd) Value() string {
+	return b.value
+}
+
+func (b *BlockingPopCmd) Found() bool {
+	return b.found
+}
+
+func keyArgs(key string, values []string) []interface{} {
+	args := make([]interface{}, 0, len(values)+1)
+	args = append(args, key)
+
+	for _, value := range values {
+		args = append(args, value)
+	}
+
+	return args
+}
+
+func blockingArgs(keys []string, timeout int) []interface{} {
+	args := make([]interface{}, 0, len(keys)+1)
+
+	for _, key := range keys {
+		args = append(args, key)
+	}
+
+	return append(args, timeout)
+}
+
+// getBlockingPop returns the key popped from and its value, not found once
+// the timeout is over
+func getBlockingPop(value interface{}, err error) (string, string, bool, error) {
+	values, err := redis.Strings(value, err)
+
+	if err == redis.ErrNil {
+		return "", "", false, nil
+	}
+
+	if err != nil {
+		return "", "", false, err
+	}
+
+	if len(values) != 2 {
+		return "", "", false, errors.New("unexpected blocking pop reply")
+	}
+
+	return values[0], values[1], true, nil
+}
@@ -0,0 +1,289 @@
+package redis
+
+import (
+	"time"
+)
+
+// mockList holds the items of a list, head first
+type mockList []string
+
+// getList returns the list at key, nil if missing. Lock must be held.
+func (r *RedisMock) getList(key string) (mockList, error) {
+	if r.failsOnGet[key] {
+		return nil, mockFailure("get")
+	}
+
+	obj := r.lookup(key)
+
+	if obj == nil {
+		return nil, nil
+	}
+
+	list, ok := obj.data.(mockList)
+
+	if !ok {
+		return nil, mockWrongType()
+	}
+
+	return list, nil
+}
+
+// storeList stores list at key, keeping its ttl, and removes the key once
+// empty as redis does. Lock must be held.
+func (r *RedisMock) storeList(key string, list mockList) error {
+	if r.failsOnSet[key] {
+		return mockFailure("set")
+	}
+
+	if len(list) == 0 {
+		delete(r.db, key)
+		return nil
+	}
+
+	if obj := r.lookup(key); obj != nil {
+		obj.data = list
+		return nil
+	}
+
+	r.db[key] = &RedisMockObject{data: list}
+	return nil
+}
+
+// pop removes the head or the tail of the list at key. Lock must be held.
+func (r *RedisMock) pop(key string, left bool) (string, bool, error) {
+	list, err := r.getList(key)
+
+	if err != nil || len(list) == 0 {
+		return "", false, err
+	}
+
+	value := list[len(list)-1]
+	rest := list[:len(list)-1]
+
+	if left {
+		value = list[0]
+		rest = list[1:]
+	}
+
+	return value, true, r.storeList(key, rest)
+}
+
+func (r *RedisMock) push(key string, values []string, left bool) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	list, err := r.getList(key)
+
+	if err != nil {
+		return 0, err
+	}
+
+	for _, value := range values {
+		if left {
+			list = append(mockList{value}, list...)
+		} else {
+			list = append(list, value)
+		}
+	}
+
+	if err := r.storeList(key, list); err != nil {
+		return 0, err
+	}
+
+	r.wakeUp()
+
+	return len(list), nil
+}
+
+func (c *RedisConnectionMock) LPush(key string, values ...string) (int, error) {
+	return c.redis.push(key, values, true)
+}
+
+func (c *RedisConnectionMock) RPush(key string, values ...string) (int, error) {
+	return c.redis.push(key, values, false)
+}
+
+func (c *RedisConnectionMock) LPop(key string) (string, bool, error) {
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
+	return c.redis.pop(key, true)
+}
+
+func (c *RedisConnectionMock) RPop(key string) (string, bool, error) {
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
+	return c.redis.pop(key, false)
+}
+
+func (c *RedisConnectionMock) LRange(key string, start int, stop int) ([]string, error) {
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
+	list, err := c.redis.getList(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	start, stop = mockRange(start, stop, len(list))
+
+	if start > stop {
+		return []string{}, nil
+	}
+
+	return append([]string{}, list[start:stop+1]...), nil
+}
+
+func (c *RedisConnectionMock) LTrim(key string, start int, stop int) error {
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
+	list, err := c.redis.getList(key)
+
+	if err != nil {
+		return err
+	}
+
+	start, stop = mockRange(start, stop, len(list))
+
+	if start > stop {
+		return c.redis.storeList(key, nil)
+	}
+
+	return c.redis.storeList(key, list[start:stop+1])
+}
+
+func (c *RedisConnectionMock) LLen(key string) (int, error) {
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
+	list, err := c.redis.getList(key)
+	return len(list), err
+}
+
+func (c *RedisConnectionMock) BLPop(timeout int, keys ...string) (string, string, bool, error) {
+	return c.blockingPop(timeout, true, keys)
+}
+
+func (c *RedisConnectionMock) BRPop(timeout int, keys ...string) (string, string, bool, error) {
+	return c.blockingPop(timeout, false, keys)
+}
+
+// blockingPop waits for a push on any connection, timeout is in real sec
+func (c *RedisConnectionMock) blockingPop(timeout int, left bool, keys []string) (string, string, bool, error) {
+	var deadline <-chan time.Time
+
+	if timeout > 0 {
+		timer := time.NewTimer(time.Duration(timeout) * time.Second)
+		defer timer.Stop()
+
+		deadline = timer.C
+	}
+
+	for {
+		c.redis.mu.Lock()
+
+		for _, key := range keys {
+			value, found, err := c.redis.pop(key, left)
+
+			if err != nil || found {
+				c.redis.mu.Unlock()
+				return key, value, found, err
+			}
+		}
+
+		pushed := c.redis.pushed
+		c.redis.mu.Unlock()
+
+		select {
+		case <-pushed:
+		case <-deadline:
+			return "", "", false, nil
+		}
+	}
+}
+
+// mockRange resolves redis start and stop indexes, negative ones counting
+// from the end, into bounds of a sequence of length items
+func mockRange(start int, stop int, length int) (int, int) {
+	if start < 0 {
+		start += length
+	}
+
+	if stop < 0 {
+		stop += length
+	}
+
+	if start < 0 {
+		start = 0
+	}
+
+	if stop >= length {
+		stop = length - 1
+	}
+
+	return start, stop
+}
+
+func (p *PipelineMock) LPush(key string, values ...string) *PushCmd {
+	cmd := PushCmd{key: key, values: values, left: true}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) RPush(key string, values ...string) *PushCmd {
+	cmd := PushCmd{key: key, values: values}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) LPop(key string) *PopCmd {
+	cmd := PopCmd{key: key, left: true}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) RPop(key string) *PopCmd {
+	cmd := PopCmd{key: key}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) LRange(key string, start int, stop int) *LRangeCmd {
+	cmd := LRangeCmd{key: key, start: start, stop: stop}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) LTrim(key string, start int, stop int) {
+	cmd := LTrimCmd{key: key, start: start, stop: stop}
+	p.cmds = append(p.cmds, &cmd)
+}
+
+func (p *PipelineMock) LLen(key string) *LLenCmd {
+	cmd := LLenCmd{key: key}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) BLPop(timeout int, keys ...string) *BlockingPopCmd {
+	cmd := BlockingPopCmd{timeout: timeout, keys: keys, left: true}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) BRPop(timeout int, keys ...string) *BlockingPopCmd {
+	cmd := BlockingPopCmd{timeout: timeout, keys: keys}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
@@ -0,0 +1,71 @@
+package redis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestList(t *testing.T) {
+
+	t.Run("push pop range trim", func(t *testing.T) {
+		conn := MockRedis().Connection()
+
+		num, err := conn.RPush("l", "b", "c")
+		assert.Nil(t, err, "must succeed")
+		assert.Equal(t, 2, num)
+
+		num, _ = conn.LPush("l", "a", "z")
+		assert.Equal(t, 4, num)
+
+		values, _ := conn.LRange("l", 0, -1)
+		assert.Equal(t, []string{"z", "a", "b", "c"}, values)
+
+		conn.LTrim("l", 1, -1)
+		value, found, _ := conn.LPop("l")
+		assert.True(t, found, "must pop")
+		assert.Equal(t, "a", value)
+
+		value, _, _ = conn.RPop("l")
+		assert.Equal(t, "c", value)
+
+		conn.RPop("l")
+		_, found, _ = conn.RPop("l")
+		assert.False(t, found, "list must be empty")
+
+		exists, _ := conn.Exists("l")
+		assert.False(t, exists, "empty list must be removed")
+	})
+
+	t.Run("pipeline", func(t *testing.T) {
+		conn := MockRedis().Connection()
+
+		pipe := conn.Pipeline()
+		pipe.RPush("log", "1", "2", "3")
+		pipe.LTrim("log", -2, -1)
+		length := pipe.LLen("log")
+		values := pipe.LRange("log", 0, -1)
+		pop := pipe.LPop("log")
+
+		assert.Nil(t, pipe.Exec(), "must succeed")
+		assert.Equal(t, 2, length.Value())
+		assert.Equal(t, []string{"2", "3"}, values.Value())
+		assert.Equal(t, "2", pop.Value())
+	})
+
+	t.Run("blocking pop", func(t *testing.T) {
+		r := MockRedis()
+		conn := r.Connection()
+
+		go r.Connection().RPush("q2", "job")
+
+		key, value, found, err := conn.BLPop(5, "q1", "q2")
+		assert.Nil(t, err, "must succeed")
+		assert.True(t, found, "must pop")
+		assert.Equal(t, "q2", key)
+		assert.Equal(t, "job", value)
+
+		_, _, found, _ = conn.BRPop(1, "q1")
+		assert.False(t, found, "must time out")
+	})
+}
@@ -0,0 +1,589 @@
+package redis
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type LocalCacheOptions struct {
+	// Size is the max number of keys kept in process, 0 or less keeping none
+	Size int
+	// TTL bounds how long a key is served from process, it should be short
+	// as keys expiring in redis are not noticed
+	TTL time.Duration
+	// Channel is the pub/sub channel used to broadcast invalidations
+	Channel string
+	// ResubscribeDelay is the wait before subscribing again to Channel after a
+	// connection error, 1s by default
+	ResubscribeDelay time.Duration
+}
+
+// LocalCacheStats counts reads served by each tier
+type LocalCacheStats struct {
+	LocalHits   uint64
+	LocalMisses uint64
+	RedisHits   uint64
+	RedisMisses uint64
+}
+
+func (s LocalCacheStats) LocalHitRatio() float64 {
+	return hitRatio(s.LocalHits, s.LocalMisses)
+}
+
+func (s LocalCacheStats) RedisHitRatio() float64 {
+	return hitRatio(s.RedisHits, s.RedisMisses)
+}
+
+func hitRatio(hits uint64, misses uint64) float64 {
+	if hits+misses == 0 {
+		return 0
+	}
+
+	return float64(hits) / float64(hits+misses)
+}
+
+// LocalCache is a Redis keeping GetString and GetInt results in a process
+// LRU. Writes made through its connections and pipelines, scripts and Do
+// included, invalidate the keys in every process sharing the invalidation
+// channel. Writes made by other clients are only noticed once the local TTL
+// is over.
+type LocalCache struct {
+	redis   Redis
+	options LocalCacheOptions
+	lru     *lru
+
+	localHits   atomic.Uint64
+	localMisses atomic.Uint64
+	redisHits   atomic.Uint64
+	redisMisses atomic.Uint64
+
+	mu     sync.Mutex
+	sub    Subscribe
+	closed bool
+	// done is closed by Close, interrupting the reconnection backoff
+	done chan struct{}
+	// stopped is closed once listen returns
+	stopped chan struct{}
+}
+
+func NewLocalCache(r Redis, options LocalCacheOptions) *LocalCache {
+	if options.ResubscribeDelay <= 0 {
+		options.ResubscribeDelay = time.Second
+	}
+
+	c := &LocalCache{
+		redis:   r,
+		options: options,
+		lru:     newLRU(options.Size, options.TTL),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	conn := r.Connection()
+	c.sub = conn.Subscribe(options.Channel)
+
+	go c.listen(conn)
+	return c
+}
+
+func (c *LocalCache) Connection() RedisConnection {
+	return &localCacheConnection{
+		RedisConnection: c.redis.Connection(),
+		cache:           c,
+	}
+}
+
+func (c *LocalCache) Stats() LocalCacheStats {
+	return LocalCacheStats{
+		LocalHits:   c.localHits.Load(),
+		LocalMisses: c.localMisses.Load(),
+		RedisHits:   c.redisHits.Load(),
+		RedisMisses: c.redisMisses.Load(),
+	}
+}
+
+// Close stops listening to invalidations
+func (c *LocalCache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil
+	}
+
+	c.closed = true
+	close(c.done)
+
+	if sub, ok := c.sub.(Unsubscriber); ok {
+		return sub.Unsubscribe()
+	}
+
+	return nil
+}
+
+// listen drops invalidated keys, resubscribing on connection errors. The whole
+// LRU is dropped after an error as invalidations may have been missed.
+func (c *LocalCache) listen(conn RedisConnection) {
+	defer close(c.stopped)
+
+	for {
+		c.mu.Lock()
+		sub := c.sub
+		c.mu.Unlock()
+
+		for data := sub.GetData(); data != nil; data = sub.GetData() {
+			c.lru.remove(string(data))
+		}
+
+		conn.Close()
+		c.lru.clear()
+
+		c.mu.Lock()
+		if c.closed {
+			c.mu.Unlock()
+			return
+		}
+		c.mu.Unlock()
+
+		select {
+		case <-c.done:
+			return
+		case <-time.After(c.options.ResubscribeDelay):
+		}
+
+		conn = c.redis.Connection()
+
+		c.mu.Lock()
+		if c.closed {
+			c.mu.Unlock()
+			conn.Close()
+			return
+		}
+
+		c.sub = conn.Subscribe(c.options.Channel)
+		c.mu.Unlock()
+	}
+}
+
+// localCacheGet reads key from the process first, then from redis with fetch.
+// A value fetched while key is invalidated isn't kept, as it may be stale.
+func localCacheGet[T any](c *LocalCache, key string, fetch func(key string) (T, bool, error)) (T, bool, error) {
+	if value, ok := c.lru.get(key); ok {
+		if value, ok := value.(T); ok {
+			c.localHits.Add(1)
+			return value, true, nil
+		}
+	}
+
+	c.localMisses.Add(1)
+	version := c.lru.fetching(key)
+	value, found, err := fetch(key)
+	c.lru.fetched(key, version, value, err == nil && found)
+
+	if err != nil {
+		return value, found, err
+	}
+
+	if found {
+		c.redisHits.Add(1)
+	} else {
+		c.redisMisses.Add(1)
+	}
+
+	return value, found, nil
+}
+
+// invalidate drops keys from the process and broadcasts them in a single
+// round trip, one message per key
+func (c *LocalCache) invalidate(conn RedisConnection, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	pipe := conn.Pipeline()
+
+	for _, key := range keys {
+		c.lru.remove(key)
+		pipe.Publish(c.options.Channel, []byte(key))
+	}
+
+	if err := pipe.Exec(); err != nil {
+		return fmt.Errorf("local cache invalidation: %w", err)
+	}
+
+	return nil
+}
+
+// localCacheReads are the commands sent with Do known not to write
+var localCacheReads = map[string]bool{
+	"GET": true, "MGET": true, "EXISTS": true, "TYPE": true, "TTL": true, "PTTL": true,
+	"STRLEN": true, "GETRANGE": true, "GETBIT": true, "BITCOUNT": true, "PFCOUNT": true,
+	"PING": true, "TIME": true, "ECHO": true, "SCAN": true,
+}
+
+// writtenKeys returns the keys command may write
+func writtenKeys(command string, args []interface{}) []string {
+	name := strings.ToUpper(command)
+
+	if localCacheReads[name] || len(args) == 0 {
+		return nil
+	}
+
+	keys := []string{}
+
+	switch name {
+	case "EVAL", "EVALSHA":
+		if len(args) < 2 {
+			return nil
+		}
+
+		num, _ := strconv.Atoi(redisArg(args[1]))
+
+		for i := 2; i < len(args) && i < num+2; i++ {
+			keys = append(keys, redisArg(args[i]))
+		}
+	case "DEL", "UNLINK":
+		for _, arg := range args {
+			keys = append(keys, redisArg(arg))
+		}
+	case "MSET", "MSETNX":
+		for i := 0; i < len(args); i += 2 {
+			keys = append(keys, redisArg(args[i]))
+		}
+	default:
+		keys = append(keys, redisArg(args[0]))
+	}
+
+	return keys
+}
+
+// redisArg prints arg as redis receives it
+func redisArg(arg interface{}) string {
+	if b, ok := arg.([]byte); ok {
+		return string(b)
+	}
+
+	return fmt.Sprint(arg)
+}
+
+type localCacheConnection struct {
+	RedisConnection
+	cache *LocalCache
+}
+
+func (c *localCacheConnection) WithRetry(policy RetryPolicy) RedisConnection {
+	return &localCacheConnection{
+		RedisConnection: c.RedisConnection.WithRetry(policy),
+		cache:           c.cache,
+	}
+}
+
+func (c *localCacheConnection) WithContext(ctx context.Context) RedisConnection {
+	return &localCacheConnection{
+		RedisConnection: c.RedisConnection.WithContext(ctx),
+		cache:           c.cache,
+	}
+}
+
+func (c *localCacheConnection) GetString(key string) (string, bool, error) {
+	return localCacheGet(c.cache, key, c.RedisConnection.GetString)
+}
+
+func (c *localCacheConnection) GetInt(key string) (int, bool, error) {
+	return localCacheGet(c.cache, key, c.RedisConnection.GetInt)
+}
+
+// invalidated invalidates keys once written, returning the error of the
+// write first and then the one of the invalidation
+func (c *localCacheConnection) invalidated(err error, keys ...string) error {
+	if invalidateErr := c.cache.invalidate(c.RedisConnection, keys...); err == nil {
+		return invalidateErr
+	}
+
+	return err
+}
+
+func (c *localCacheConnection) SetString(key string, value string, ttl int) error {
+	return c.invalidated(c.RedisConnection.SetString(key, value, ttl), key)
+}
+
+func (c *localCacheConnection) SetInt(key string, value int, ttl int) error {
+	return c.invalidated(c.RedisConnection.SetInt(key, value, ttl), key)
+}
+
+func (c *localCacheConnection) IncrBy(key string, by int) (int, error) {
+	value, err := c.RedisConnection.IncrBy(key, by)
+	return value, c.invalidated(err, key)
+}
+
+func (c *localCacheConnection) Delete(keys ...string) (int, error) {
+	num, err := c.RedisConnection.Delete(keys...)
+	return num, c.invalidated(err, keys...)
+}
+
+func (c *localCacheConnection) Unlink(keys ...string) (int, error) {
+	num, err := c.RedisConnection.Unlink(keys...)
+	return num, c.invalidated(err, keys...)
+}
+
+func (c *localCacheConnection) PFAdd(key string, elements ...string) (bool, error) {
+	changed, err := c.RedisConnection.PFAdd(key, elements...)
+	return changed, c.invalidated(err, key)
+}
+
+func (c *localCacheConnection) PFMerge(dest string, keys ...string) error {
+	return c.invalidated(c.RedisConnection.PFMerge(dest, keys...), dest)
+}
+
+func (c *localCacheConnection) SetBit(key string, offset int, value bool) (bool, error) {
+	previous, err := c.RedisConnection.SetBit(key, offset, value)
+	return previous, c.invalidated(err, key)
+}
+
+func (c *localCacheConnection) BitOp(op string, dest string, keys ...string) (int, error) {
+	size, err := c.RedisConnection.BitOp(op, dest, keys...)
+	return size, c.invalidated(err, dest)
+}
+
+func (c *localCacheConnection) BitField(key string, ops ...BitFieldOp) ([]BitFieldValue, error) {
+	values, err := c.RedisConnection.BitField(key, ops...)
+	return values, c.invalidated(err, key)
+}
+
+func (c *localCacheConnection) Eval(script *Script, keys []string, args ...interface{}) (interface{}, error) {
+	value, err := c.RedisConnection.Eval(script, keys, args...)
+	return value, c.invalidated(err, keys...)
+}
+
+func (c *localCacheConnection) Do(command string, args ...interface{}) (Reply, error) {
+	reply, err := c.RedisConnection.Do(command, args...)
+	return reply, c.invalidated(err, writtenKeys(command, args)...)
+}
+
+func (c *localCacheConnection) Pipeline() Pipeline {
+	return &localCachePipeline{
+		Pipeline: c.RedisConnection.Pipeline(),
+		conn:     c,
+	}
+}
+
+// localCachePipeline invalidates the keys written once executed
+type localCachePipeline struct {
+	Pipeline
+	conn *localCacheConnection
+	keys []string
+}
+
+func (p *localCachePipeline) SetInt(key string, value int, ttl int) {
+	p.keys = append(p.keys, key)
+	p.Pipeline.SetInt(key, value, ttl)
+}
+
+func (p *localCachePipeline) SetString(key string, value string, ttl int) {
+	p.keys = append(p.keys, key)
+	p.Pipeline.SetString(key, value, ttl)
+}
+
+func (p *localCachePipeline) IncrBy(key string, by int) *IncrByCmd {
+	p.keys = append(p.keys, key)
+	return p.Pipeline.IncrBy(key, by)
+}
+
+func (p *localCachePipeline) Delete(keys ...string) *DeleteCmd {
+	p.keys = append(p.keys, keys...)
+	return p.Pipeline.Delete(keys...)
+}
+
+func (p *localCachePipeline) Unlink(keys ...string) *DeleteCmd {
+	p.keys = append(p.keys, keys...)
+	return p.Pipeline.Unlink(keys...)
+}
+
+func (p *localCachePipeline) PFAdd(key string, elements ...string) *PFAddCmd {
+	p.keys = append(p.keys, key)
+	return p.Pipeline.PFAdd(key, elements...)
+}
+
+func (p *localCachePipeline) PFMerge(dest string, keys ...string) *PFMergeCmd {
+	p.keys = append(p.keys, dest)
+	return p.Pipeline.PFMerge(dest, keys...)
+}
+
+func (p *localCachePipeline) SetBit(key string, offset int, value bool) *SetBitCmd {
+	p.keys = append(p.keys, key)
+	return p.Pipeline.SetBit(key, offset, value)
+}
+
+func (p *localCachePipeline) BitOp(op string, dest string, keys ...string) *BitOpCmd {
+	p.keys = append(p.keys, dest)
+	return p.Pipeline.BitOp(op, dest, keys...)
+}
+
+func (p *localCachePipeline) BitField(key string, ops ...BitFieldOp) *BitFieldCmd {
+	p.keys = append(p.keys, key)
+	return p.Pipeline.BitField(key, ops...)
+}
+
+func (p *localCachePipeline) Eval(script *Script, keys []string, args ...interface{}) *EvalCmd {
+	p.keys = append(p.keys, keys...)
+	return p.Pipeline.Eval(script, keys, args...)
+}
+
+func (p *localCachePipeline) Do(command string, args ...interface{}) *DoCmd {
+	p.keys = append(p.keys, writtenKeys(command, args)...)
+	return p.Pipeline.Do(command, args...)
+}
+
+func (p *localCachePipeline) Exec() error {
+	return p.conn.invalidated(p.Pipeline.Exec(), p.keys...)
+}
+
+// lru is a size bounded, ttl bounded, least recently used cache
+type lru struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	entries map[string]*list.Element
+	order   *list.List
+	// fetches versions the keys being fetched, removing a key changing its
+	// version
+	fetches map[string]*lruFetch
+}
+
+type lruFetch struct {
+	version uint64
+	running int
+}
+
+type lruEntry struct {
+	key       string
+	value     interface{}
+	expiresAt time.Time
+}
+
+func newLRU(size int, ttl time.Duration) *lru {
+	return &lru{
+		size:    size,
+		ttl:     ttl,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+		fetches: make(map[string]*lruFetch),
+	}
+}
+
+func (l *lru) get(key string) (interface{}, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	elem := l.entries[key]
+
+	if elem == nil {
+		return nil, false
+	}
+
+	entry := elem.Value.(*lruEntry)
+
+	if time.Now().After(entry.expiresAt) {
+		l.order.Remove(elem)
+		delete(l.entries, key)
+		return nil, false
+	}
+
+	l.order.MoveToFront(elem)
+	return entry.value, true
+}
+
+// fetching returns the version of key before it is fetched
+func (l *lru) fetching(key string) uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	fetch := l.fetches[key]
+
+	if fetch == nil {
+		fetch = &lruFetch{}
+		l.fetches[key] = fetch
+	}
+
+	fetch.running++
+	return fetch.version
+}
+
+// fetched adds value at key if found and key wasn't removed since its version
+func (l *lru) fetched(key string, version uint64, value interface{}, found bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	fetch := l.fetches[key]
+	fetch.running--
+
+	if fetch.running == 0 {
+		delete(l.fetches, key)
+	}
+
+	if found && fetch.version == version {
+		l.set(key, value)
+	}
+}
+
+func (l *lru) add(key string, value interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.set(key, value)
+}
+
+// set adds value at key. Lock must be held.
+func (l *lru) set(key string, value interface{}) {
+	entry := &lruEntry{
+		key:       key,
+		value:     value,
+		expiresAt: time.Now().Add(l.ttl),
+	}
+
+	if elem := l.entries[key]; elem != nil {
+		elem.Value = entry
+		l.order.MoveToFront(elem)
+		return
+	}
+
+	l.entries[key] = l.order.PushFront(entry)
+
+	for l.order.Len() > l.size {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.entries, oldest.Value.(*lruEntry).key)
+	}
+}
+
+func (l *lru) remove(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if fetch := l.fetches[key]; fetch != nil {
+		fetch.version++
+	}
+
+	if elem := l.entries[key]; elem != nil {
+		l.order.Remove(elem)
+		delete(l.entries, key)
+	}
+}
+
+func (l *lru) clear() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries = make(map[string]*list.Element)
+	l.order.Init()
+
+	for _, fetch := range l.fetches {
+		fetch.version++
+	}
+}
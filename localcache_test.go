@@ -0,0 +1,171 @@
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalCache(t *testing.T) {
+
+	t.Run("serves reads from process", func(t *testing.T) {
+		r := MockRedis().With("k", "v1", 0)
+		cache := NewLocalCache(r, LocalCacheOptions{Size: 10, TTL: time.Minute, Channel: "inval"})
+		defer cache.Close()
+
+		conn := cache.Connection()
+		defer conn.Close()
+
+		value, found, err := conn.GetString("k")
+		assert.Nil(t, err, "must succeed")
+		assert.True(t, found, "k must be found")
+		assert.Equal(t, "v1", value)
+
+		r.With("k", "changed behind the cache", 0)
+		value, _, _ = conn.GetString("k")
+		assert.Equal(t, "v1", value, "k must be served from process")
+
+		_, found, _ = conn.GetString("missing")
+		assert.False(t, found, "missing must not be found")
+
+		assert.Equal(t, LocalCacheStats{LocalHits: 1, LocalMisses: 2, RedisHits: 1, RedisMisses: 1}, cache.Stats())
+		assert.Equal(t, 1.0/3, cache.Stats().LocalHitRatio())
+	})
+
+	t.Run("invalidates other processes", func(t *testing.T) {
+		r := MockRedis().With("k", 1, 0)
+		cacheA := NewLocalCache(r, LocalCacheOptions{Size: 10, TTL: time.Minute, Channel: "inval"})
+		defer cacheA.Close()
+		cacheB := NewLocalCache(r, LocalCacheOptions{Size: 10, TTL: time.Minute, Channel: "inval"})
+		defer cacheB.Close()
+
+		connA := cacheA.Connection()
+		connB := cacheB.Connection()
+
+		value, _, _ := connA.GetInt("k")
+		assert.Equal(t, 1, value)
+
+		pipe := connB.Pipeline()
+		pipe.SetInt("k", 2, 0)
+		assert.Nil(t, pipe.Exec(), "must succeed")
+
+		deadline := time.Now().Add(time.Second)
+		for value == 1 && time.Now().Before(deadline) {
+			value, _, _ = connA.GetInt("k")
+		}
+		assert.Equal(t, 2, value, "k must be invalidated")
+	})
+
+	t.Run("invalidates in one round trip", func(t *testing.T) {
+		r := MockRedis()
+		cache := NewLocalCache(r, LocalCacheOptions{Size: 10, TTL: time.Minute, Channel: "inval"})
+		defer cache.Close()
+
+		conn := cache.Connection()
+		defer conn.Close()
+
+		r.ResetCommands()
+		conn.Delete("a", "b", "c")
+
+		publishes := []RecordedCommand{}
+		for _, cmd := range r.Commands() {
+			if cmd.Name == "PUBLISH" {
+				publishes = append(publishes, cmd)
+			}
+		}
+
+		assert.Equal(t, 3, len(publishes), "one message per key")
+		assert.NotEqual(t, 0, publishes[0].Pipeline, "must be pipelined")
+		assert.Equal(t, publishes[0].Pipeline, publishes[2].Pipeline, "must share a pipeline")
+	})
+
+	t.Run("closes while reconnecting", func(t *testing.T) {
+		r := MockRedis()
+		cache := NewLocalCache(r, LocalCacheOptions{Size: 10, TTL: time.Minute, Channel: "inval", ResubscribeDelay: time.Hour})
+
+		// drops the subscription as a connection error would
+		cache.mu.Lock()
+		cache.sub.(Unsubscriber).Unsubscribe()
+		cache.mu.Unlock()
+
+		cache.Close()
+		<-cache.stopped
+		assert.Equal(t, 0, r.GetOpenedConnections(), "must not resubscribe once closed")
+	})
+
+	t.Run("invalidates every writer", func(t *testing.T) {
+		r := MockRedis().With("k", "a", 0)
+		cache := NewLocalCache(r, LocalCacheOptions{Size: 10, TTL: time.Minute, Channel: "inval"})
+		defer cache.Close()
+
+		conn := cache.Connection()
+		defer conn.Close()
+
+		conn.GetString("k")
+		conn.Do("DEL", "k")
+		_, found, _ := conn.GetString("k")
+		assert.False(t, found, "Do must invalidate")
+
+		r.With("k", "a", 0)
+		conn.GetString("k")
+		conn.SetBit("k", 2, false)
+		value, _, _ := conn.GetString("k")
+		assert.Equal(t, "A", value, "SetBit must invalidate")
+
+		limiter := NewFixedWindowLimiter(10, time.Minute)
+		limiter.Allow("rl", conn)
+		count, _, _ := conn.GetInt("rl")
+		limiter.Allow("rl", conn)
+		count, _, _ = conn.GetInt("rl")
+		assert.Equal(t, 2, count, "scripts must invalidate")
+
+		pipe := conn.Pipeline()
+		pipe.Do("DEL", "rl")
+		assert.Nil(t, pipe.Exec(), "must succeed")
+		_, found, _ = conn.GetInt("rl")
+		assert.False(t, found, "pipelined Do must invalidate")
+	})
+
+	t.Run("fails when not broadcast", func(t *testing.T) {
+		r := MockRedis()
+		cache := NewLocalCache(r, LocalCacheOptions{Size: 10, TTL: time.Minute, Channel: "inval"})
+		defer cache.Close()
+
+		conn := cache.Connection()
+		defer conn.Close()
+
+		r.InjectFault(Fault{Command: "PUBLISH"})
+		err := conn.SetString("k", "v", 0)
+		assert.ErrorIs(t, err, ErrConnection, "failed invalidations must be returned")
+	})
+
+	t.Run("drops values fetched while invalidated", func(t *testing.T) {
+		l := newLRU(2, time.Minute)
+
+		version := l.fetching("k")
+		l.remove("k")
+		l.fetched("k", version, 1, true)
+		_, found := l.get("k")
+		assert.False(t, found, "value read before the invalidation must not be kept")
+
+		version = l.fetching("k")
+		l.fetched("k", version, 2, true)
+		value, _ := l.get("k")
+		assert.Equal(t, 2, value)
+		assert.Empty(t, l.fetches, "finished fetches must be forgotten")
+	})
+
+	t.Run("evicts least recently used", func(t *testing.T) {
+		l := newLRU(2, time.Minute)
+		l.add("a", 1)
+		l.add("b", 2)
+		l.get("a")
+		l.add("c", 3)
+
+		_, found := l.get("b")
+		assert.False(t, found, "b must be evicted")
+		_, found = l.get("a")
+		assert.True(t, found, "a must be kept")
+	})
+}
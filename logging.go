@@ -0,0 +1,102 @@
+package redis
+
+import (
+	"log/slog"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// LogOptions configure the logs of WithLogger
+type LogOptions struct {
+	// SlowThreshold logs the commands and pipelines lasting longer, 0 not
+	// logging slow commands
+	SlowThreshold time.Duration
+	// RedactKey transforms the keys logged, such as KeyPrefix, nil logging
+	// them as is. Other args are never logged.
+	RedactKey func(key string) string
+	// SampleRate is the fraction of the logs kept, 0 keeping them all
+	SampleRate float64
+}
+
+// WithLogger logs command failures, pool dial errors and slow commands to
+// logger
+func WithLogger(logger *slog.Logger, options LogOptions) Option {
+	return func(o *redisOptions) {
+		o.logger = logger
+		o.logOptions = options
+	}
+}
+
+// KeyPrefix redacts keys after their last ':', "user:42" being logged as
+// "user:?"
+func KeyPrefix(key string) string {
+	if i := strings.LastIndexByte(key, ':'); i >= 0 {
+		return key[:i+1] + "?"
+	}
+
+	return "?"
+}
+
+func (o *redisOptions) sampled() bool {
+	return o.logOptions.SampleRate <= 0 || o.logOptions.SampleRate >= 1 || rand.Float64() < o.logOptions.SampleRate
+}
+
+// logCommand logs operation when failed or slow
+func (o *redisOptions) logCommand(operation string, args []interface{}, duration time.Duration, err error) {
+	if o.logger == nil {
+		return
+	}
+
+	slow := o.logOptions.SlowThreshold > 0 && duration > o.logOptions.SlowThreshold
+
+	if err == nil && !slow || !o.sampled() {
+		return
+	}
+
+	attrs := []any{
+		slog.String("command", operation),
+		slog.String("statement", sanitizeStatement(operation, args, o.logOptions.RedactKey)),
+		slog.Duration("duration", duration),
+	}
+
+	if err != nil {
+		o.logger.Error("redis command failed", append(attrs, slog.String("error", err.Error()))...)
+		return
+	}
+
+	o.logger.Warn("slow redis command", attrs...)
+}
+
+// logPipeline logs a pipeline of size commands when failed or slow
+func (o *redisOptions) logPipeline(size int, duration time.Duration, err error) {
+	if o.logger == nil {
+		return
+	}
+
+	slow := o.logOptions.SlowThreshold > 0 && duration > o.logOptions.SlowThreshold
+
+	if err == nil && !slow || !o.sampled() {
+		return
+	}
+
+	attrs := []any{
+		slog.Int("size", size),
+		slog.Duration("duration", duration),
+	}
+
+	if err != nil {
+		o.logger.Error("redis pipeline failed", append(attrs, slog.String("error", err.Error()))...)
+		return
+	}
+
+	o.logger.Warn("slow redis pipeline", attrs...)
+}
+
+func (o *redisOptions) logDial(address string, err error) {
+	if o.logger == nil || !o.sampled() {
+		return
+	}
+
+	o.logger.Error("redis dial failed", slog.String("address", address), slog.String("error", err.Error()))
+}
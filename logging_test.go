@@ -0,0 +1,70 @@
+package redis
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+// slowConn takes a while to reply to Do
+type slowConn struct {
+	echoConn
+}
+
+func (c *slowConn) Do(command string, args ...interface{}) (interface{}, error) {
+	time.Sleep(20 * time.Millisecond)
+	return nil, nil
+}
+
+func TestLogging(t *testing.T) {
+	logs := &bytes.Buffer{}
+	logger := slog.New(slog.NewTextHandler(logs, nil))
+	option := WithLogger(logger, LogOptions{SlowThreshold: 10 * time.Millisecond, RedactKey: KeyPrefix})
+
+	slow := &RedisImpl{
+		pool: &redis.Pool{Dial: func() (redis.Conn, error) {
+			return &slowConn{echoConn{mu: &sync.Mutex{}, flushes: &[]int{}}}, nil
+		}},
+		options: newRedisOptions([]Option{option}),
+	}
+
+	slow.Connection().GetString("user:42")
+	assert.Contains(t, logs.String(), `level=WARN msg="slow redis command" command=GET statement="GET user:?"`)
+	assert.NotContains(t, logs.String(), "user:42", "key must be redacted")
+
+	logs.Reset()
+	failing := &RedisImpl{
+		pool: &redis.Pool{Dial: func() (redis.Conn, error) {
+			return &failingConn{}, nil
+		}},
+		options: newRedisOptions([]Option{option}),
+	}
+
+	failing.Connection().SetString("session:abc", "secret", 60)
+	assert.Contains(t, logs.String(), `level=ERROR msg="redis command failed" command=SETEX statement="SETEX session:? ? ?"`)
+	assert.Contains(t, logs.String(), `error="ERR boom"`)
+	assert.NotContains(t, logs.String(), "secret", "values must not be logged")
+
+	logs.Reset()
+	r := NewRedis("127.0.0.1:1", 1, option)
+	r.Connection().GetString("key")
+	assert.Contains(t, logs.String(), `level=ERROR msg="redis dial failed" address=127.0.0.1:1`)
+
+	logs.Reset()
+	sampled := &RedisImpl{
+		pool:    failing.pool,
+		options: newRedisOptions([]Option{WithLogger(logger, LogOptions{SampleRate: 0.000001})}),
+	}
+
+	for i := 0; i < 10; i++ {
+		sampled.Connection().GetString("key")
+	}
+
+	assert.Less(t, strings.Count(logs.String(), "\n"), 10, "logs must be sampled")
+}
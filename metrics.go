@@ -0,0 +1,85 @@
+package redis
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// Metrics receives the measures of a client configured with WithMetrics.
+// errType is empty on success, see errorType otherwise. The redisprom
+// package adapts it to Prometheus.
+type Metrics interface {
+	ObserveCommand(command string, duration time.Duration, errType string)
+	ObservePipeline(size int, duration time.Duration, errType string)
+	// ObservePool is given, once the pool is created, a func reading its
+	// stats, to be called whenever they are exported
+	ObservePool(stats func() PoolStats)
+}
+
+// PoolStats are the connection pool counters
+type PoolStats struct {
+	ActiveCount int
+	IdleCount   int
+	// WaitCount and WaitDuration add up the waits for a connection, only
+	// happening when the pool is limited
+	WaitCount    int64
+	WaitDuration time.Duration
+}
+
+// WithMetrics reports command latencies, errors, pipeline sizes and pool
+// stats to metrics
+func WithMetrics(metrics Metrics) Option {
+	return func(o *redisOptions) {
+		o.metrics = metrics
+	}
+}
+
+func (r *RedisImpl) poolStats() PoolStats {
+	stats := r.pool.Stats()
+
+	return PoolStats{
+		ActiveCount:  stats.ActiveCount,
+		IdleCount:    stats.IdleCount,
+		WaitCount:    stats.WaitCount,
+		WaitDuration: stats.WaitDuration,
+	}
+}
+
+// errorType classifies err as the first word of redis errors, such as "ERR"
+// or "WRONGTYPE", "circuit_open", "timeout", "pool_exhausted" or
+// "connection"
+func errorType(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var redisErr redis.Error
+
+	if errors.As(err, &redisErr) {
+		if i := strings.IndexByte(string(redisErr), ' '); i > 0 {
+			return string(redisErr)[:i]
+		}
+
+		return string(redisErr)
+	}
+
+	if errors.Is(err, ErrCircuitOpen) {
+		return "circuit_open"
+	}
+
+	if errors.Is(err, redis.ErrPoolExhausted) {
+		return "pool_exhausted"
+	}
+
+	var netErr net.Error
+
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return "timeout"
+	}
+
+	return "connection"
+}
@@ -0,0 +1,67 @@
+package redis
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordedMetrics records the observations of commands and pipelines
+type recordedMetrics struct {
+	commands  []string
+	pipelines []int
+	pool      func() PoolStats
+}
+
+func (m *recordedMetrics) ObserveCommand(command string, duration time.Duration, errType string) {
+	m.commands = append(m.commands, command+" "+errType)
+}
+
+func (m *recordedMetrics) ObservePipeline(size int, duration time.Duration, errType string) {
+	m.pipelines = append(m.pipelines, size)
+}
+
+func (m *recordedMetrics) ObservePool(stats func() PoolStats) {
+	m.pool = stats
+}
+
+func TestMetrics(t *testing.T) {
+	metrics := &recordedMetrics{}
+	flushes := []int{}
+	dial := func() (redis.Conn, error) {
+		return &echoConn{mu: &sync.Mutex{}, flushes: &flushes}, nil
+	}
+
+	r := &RedisImpl{pool: &redis.Pool{Dial: dial, MaxIdle: 1}, options: newRedisOptions([]Option{WithMetrics(metrics)})}
+	conn := r.Connection()
+
+	conn.SetInt("a", 1, 0)
+
+	pipe := conn.Pipeline()
+	pipe.GetString("a")
+	pipe.GetString("b")
+	pipe.GetString("c")
+	assert.Nil(t, pipe.Exec(), "must succeed")
+
+	conn.Close()
+
+	assert.Equal(t, []string{"SETEX "}, metrics.commands)
+	assert.Equal(t, []int{3}, metrics.pipelines)
+	assert.Equal(t, PoolStats{ActiveCount: 1, IdleCount: 1}, r.poolStats())
+
+	NewRedis("127.0.0.1:1", 1, WithMetrics(metrics))
+	assert.NotNil(t, metrics.pool, "must be given the pool stats")
+	assert.Equal(t, PoolStats{}, metrics.pool())
+}
+
+func TestErrorType(t *testing.T) {
+	assert.Equal(t, "", errorType(nil))
+	assert.Equal(t, "WRONGTYPE", errorType(redis.Error("WRONGTYPE Operation against a key holding the wrong kind of value")))
+	assert.Equal(t, "NOSCRIPT", errorType(redis.Error("NOSCRIPT")))
+	assert.Equal(t, "pool_exhausted", errorType(redis.ErrPoolExhausted))
+	assert.Equal(t, "connection", errorType(errors.New("EOF")))
+}
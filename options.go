@@ -0,0 +1,66 @@
+package redis
+
+import (
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Option configures the client returned by NewRedis
+type Option func(*redisOptions)
+
+type redisOptions struct {
+	// pipelines are flushed every maxCommands commands or about maxBytes
+	// bytes, 0 applying no limit
+	maxCommands int
+	maxBytes    int
+	// parallelism is the max number of connections running the chunks of a
+	// pipeline at once
+	parallelism int
+	// tracer traces commands when set, see WithTracerProvider
+	tracer trace.Tracer
+	// metrics receives measures when set, see WithMetrics
+	metrics Metrics
+	// hooks are called around every command, see WithHooks
+	hooks []Hook
+	// logger logs failures and slow commands when set, see WithLogger
+	logger     *slog.Logger
+	logOptions LogOptions
+	// retry is the retry policy of connections, see WithRetryPolicy
+	retry RetryPolicy
+	// breaker guards commands when set, see WithCircuitBreaker
+	breaker *circuitBreaker
+}
+
+func newRedisOptions(options []Option) *redisOptions {
+	o := &redisOptions{
+		parallelism: 1,
+	}
+
+	for _, option := range options {
+		option(o)
+	}
+
+	return o
+}
+
+// WithPipelineChunks splits pipelines so that a flush sends at most
+// maxCommands commands and about maxBytes bytes, 0 applying no limit. Values
+// are still set on their Cmd in order, RedisBatch included.
+func WithPipelineChunks(maxCommands int, maxBytes int) Option {
+	return func(o *redisOptions) {
+		o.maxCommands = maxCommands
+		o.maxBytes = maxBytes
+	}
+}
+
+// WithPipelineParallelism runs up to conns chunks of a pipeline at once, the
+// extra connections being taken from the pool. Chunks then don't run in
+// order, which only suits pipelines of independent commands such as reads.
+func WithPipelineParallelism(conns int) Option {
+	return func(o *redisOptions) {
+		if conns > 0 {
+			o.parallelism = conns
+		}
+	}
+}
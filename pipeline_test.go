@@ -0,0 +1,179 @@
+package redis
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+// pipelineExclusions are the RedisConnection methods Pipeline doesn't mirror
+var pipelineExclusions = map[string]string{
+	"Scan":        "iterates over several round trips",
+	"Subscribe":   "holds the connection",
+	"Send":        "is Publish without the number of receivers",
+	"Pipeline":    "pipelines don't nest",
+	"Close":       "closes the connection",
+	"WithRetry":   "configures the connection",
+	"WithContext": "configures the connection",
+}
+
+// TestPipelineParity fails when a command is added to RedisConnection or
+// Pipeline without the other, or with other params, or when the pipeline
+// doesn't return a *XxxCmd whose Value is the value of the connection. It
+// can't be a compile time assertion as the same command returns its values
+// on a connection and a *XxxCmd on a pipeline, so no interface is
+// implemented by both.
+func TestPipelineParity(t *testing.T) {
+	connType := reflect.TypeOf((*RedisConnection)(nil)).Elem()
+	pipeType := reflect.TypeOf((*Pipeline)(nil)).Elem()
+
+	for i := 0; i < connType.NumMethod(); i++ {
+		method := connType.Method(i)
+
+		if _, excluded := pipelineExclusions[method.Name]; excluded {
+			continue
+		}
+
+		pipeMethod, found := pipeType.MethodByName(method.Name)
+
+		if !assert.True(t, found, "Pipeline lacks %s", method.Name) {
+			continue
+		}
+
+		assert.Equal(t, methodParams(method.Type), methodParams(pipeMethod.Type), "%s params differ", method.Name)
+		assertPipelineResult(t, method, pipeMethod)
+	}
+
+	for i := 0; i < pipeType.NumMethod(); i++ {
+		method := pipeType.Method(i)
+
+		if method.Name == "Exec" {
+			continue
+		}
+
+		_, found := connType.MethodByName(method.Name)
+		assert.True(t, found, "RedisConnection lacks %s", method.Name)
+	}
+}
+
+// assertPipelineResult asserts that pipeMethod returns a *XxxCmd, whose
+// Value returns the first value of method, or nothing if method only returns
+// an error
+func assertPipelineResult(t *testing.T, method reflect.Method, pipeMethod reflect.Method) {
+	values := method.Type.NumOut() - 1
+
+	if pipeMethod.Type.NumOut() == 0 {
+		assert.Equal(t, 0, values, "Pipeline.%s must return a *%sCmd", method.Name, method.Name)
+		return
+	}
+
+	if !assert.Equal(t, 1, pipeMethod.Type.NumOut(), "Pipeline.%s must return a single *XxxCmd", method.Name) {
+		return
+	}
+
+	cmd := pipeMethod.Type.Out(0)
+
+	if !assert.True(t, cmd.Kind() == reflect.Ptr && strings.HasSuffix(cmd.Elem().Name(), "Cmd"), "Pipeline.%s returns %v, not a *XxxCmd", method.Name, cmd) || values == 0 {
+		return
+	}
+
+	value, found := cmd.MethodByName("Value")
+
+	if assert.True(t, found, "%v lacks Value", cmd) && assert.NotZero(t, value.Type.NumOut(), "%v.Value returns nothing", cmd) {
+		assert.Equal(t, method.Type.Out(0), value.Type.Out(0), "%v.Value differs from RedisConnection.%s", cmd, method.Name)
+	}
+}
+
+func methodParams(method reflect.Type) []reflect.Type {
+	params := make([]reflect.Type, 0, method.NumIn())
+
+	for i := 0; i < method.NumIn(); i++ {
+		params = append(params, method.In(i))
+	}
+
+	return params
+}
+
+func TestPipelineGetExpire(t *testing.T) {
+	dial := func() (redis.Conn, error) {
+		return &repliesConn{
+			echoConn: echoConn{mu: &sync.Mutex{}, flushes: &[]int{}},
+			replies:  []interface{}{int64(42), int64(-2)},
+		}, nil
+	}
+
+	r := &RedisImpl{pool: &redis.Pool{Dial: dial}, options: newRedisOptions(nil)}
+	conn := r.Connection()
+	defer conn.Close()
+
+	pipe := conn.Pipeline()
+	ttl := pipe.GetExpire("a")
+	missing := pipe.GetExpire("missing")
+	assert.Nil(t, pipe.Exec(), "must succeed")
+	assert.Equal(t, 42, ttl.Value())
+	assert.Equal(t, 0, missing.Value(), "missing keys have no ttl")
+
+	mock := MockRedis().With("a", "value", 30).Connection()
+	pipe = mock.Pipeline()
+	ttl = pipe.GetExpire("a")
+	assert.Nil(t, pipe.Exec(), "must succeed")
+	assert.Equal(t, 30, ttl.Value())
+}
+
+func TestPipeline(t *testing.T) {
+	r := MockRedis()
+	conn := r.Connection()
+
+	conn.SetString("a", "1", 0)
+	conn.SetString("b", "2", 0)
+	conn.RPush("jobs", "job1")
+	conn.XGroupCreate("events", "workers", "0", true)
+	conn.XAdd("events", map[string]string{"n": "1"})
+
+	sub := r.Connection().Subscribe("news")
+	received := make(chan []byte)
+
+	go func() {
+		received <- sub.GetData()
+	}()
+
+	script := NewScript(1, "return redis.call('GET', KEYS[1])")
+	r.WithScript(script, func(conn *RedisConnectionMock, keys []string, args []interface{}) (interface{}, error) {
+		value, _, err := conn.GetString(keys[0])
+		return value, err
+	})
+
+	pipe := conn.Pipeline()
+	exists := pipe.Exists("a")
+	eval := pipe.Eval(script, []string{"b"})
+	deleted := pipe.Delete("a", "b", "c")
+	missing := pipe.Exists("a")
+	pop := pipe.BLPop(1, "jobs")
+	read := pipe.XReadGroup("workers", "w1", XReadOptions{Streams: []string{"events"}, IDs: []string{">"}})
+	pending := pipe.XPending("events", "workers", XPendingOptions{Count: 10})
+	publish := pipe.Publish("news", []byte("hello"))
+
+	assert.Nil(t, pipe.Exec(), "must succeed")
+	assert.True(t, exists.Value())
+	assert.Equal(t, "2", eval.Value())
+	assert.Equal(t, 2, deleted.Value())
+	assert.True(t, deleted.Found())
+	assert.False(t, missing.Value())
+	assert.Equal(t, "jobs", pop.Key())
+	assert.Equal(t, "job1", pop.Value())
+	assert.Equal(t, "1", read.Value()[0].Messages[0].Values["n"])
+	assert.Len(t, pending.Value(), 1)
+	assert.Equal(t, 1, publish.Value())
+
+	select {
+	case data := <-received:
+		assert.Equal(t, []byte("hello"), data)
+	case <-time.After(time.Second):
+		t.Fatal("must receive")
+	}
+}
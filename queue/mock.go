@@ -0,0 +1,281 @@
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	redis "github.com/apinet/gcloud-redis"
+)
+
+// Mock registers the go emulations of the queue scripts on r, for the tests
+// of queue users. Unlike the scripts, they aren't atomic.
+func Mock(r *redis.RedisMock) *redis.RedisMock {
+	return r.
+		WithScript(enqueueScript, mockEnqueue).
+		WithScript(dequeueScript, mockDequeue).
+		WithScript(moveJobScript, mockMoveJob).
+		WithScript(ackJobScript, mockAckJob).
+		WithScript(listJobsScript, mockListJobs)
+}
+
+// the emulations run the commands of the scripts, in the same order
+
+func mockEnqueue(conn *redis.RedisConnectionMock, keys []string, args []interface{}) (interface{}, error) {
+	if len(args) != 3 {
+		return nil, fmt.Errorf("%w: expected 3 script args, got %d", redis.ErrScript, len(args))
+	}
+
+	values, err := scriptNumbers(args[1:], 2)
+
+	if err != nil {
+		return nil, err
+	}
+
+	priority, due := values[0], values[1]
+	seq, err := conn.IncrBy(keys[3], 1)
+
+	if err != nil {
+		return nil, err
+	}
+
+	id := fmt.Sprintf("%012d", seq)
+
+	if _, err := conn.Do("HSET", keys[0], id, args[0]); err != nil {
+		return nil, err
+	}
+
+	if due > 0 {
+		_, err = conn.ZAdd(keys[2], redis.Z{Member: id, Score: due})
+	} else {
+		_, err = conn.ZAdd(keys[1], redis.Z{Member: id, Score: -priority})
+	}
+
+	return id, err
+}
+
+func mockDequeue(conn *redis.RedisConnectionMock, keys []string, args []interface{}) (interface{}, error) {
+	values, err := scriptNumbers(args, 3)
+
+	if err != nil {
+		return nil, err
+	}
+
+	now, visibility, maxAttempts := values[0], values[1], int(values[2])
+	due := redis.ZRangeOptions{Start: "-inf", Stop: args[0], ByScore: true, Count: 100}
+	timedOut, err := conn.ZRange(keys[3], due)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range timedOut {
+		if _, err := conn.ZRem(keys[3], id); err != nil {
+			return nil, err
+		}
+
+		job, err := mockJob(conn, keys[0], id)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if job.Attempts >= maxAttempts {
+			job.Error = "visibility timeout"
+
+			if _, err := mockSetJob(conn, keys[0], id, job); err != nil {
+				return nil, err
+			}
+
+			_, err = conn.ZAdd(keys[4], redis.Z{Member: id, Score: now})
+		} else {
+			_, err = conn.ZAdd(keys[1], redis.Z{Member: id, Score: float64(-job.Priority)})
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	delayed, err := conn.ZRange(keys[2], due)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range delayed {
+		if _, err := conn.ZRem(keys[2], id); err != nil {
+			return nil, err
+		}
+
+		job, err := mockJob(conn, keys[0], id)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := conn.ZAdd(keys[1], redis.Z{Member: id, Score: float64(-job.Priority)}); err != nil {
+			return nil, err
+		}
+	}
+
+	ready, err := conn.ZRange(keys[1], redis.ZRangeOptions{Start: 0, Stop: 0})
+
+	if err != nil || len(ready) == 0 {
+		return nil, err
+	}
+
+	id := ready[0]
+
+	if _, err := conn.ZRem(keys[1], id); err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.ZAdd(keys[3], redis.Z{Member: id, Score: now + visibility}); err != nil {
+		return nil, err
+	}
+
+	job, err := mockJob(conn, keys[0], id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	job.Attempts++
+	encoded, err := mockSetJob(conn, keys[0], id, job)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return []interface{}{id, encoded}, nil
+}
+
+func mockMoveJob(conn *redis.RedisConnectionMock, keys []string, args []interface{}) (interface{}, error) {
+	if len(args) != 3 {
+		return nil, fmt.Errorf("%w: expected 3 script args, got %d", redis.ErrScript, len(args))
+	}
+
+	values, err := scriptNumbers(args[1:], 1)
+
+	if err != nil {
+		return nil, err
+	}
+
+	id := fmt.Sprint(args[0])
+	removed, err := conn.ZRem(keys[1], id)
+
+	if err != nil || removed == 0 {
+		return int64(0), err
+	}
+
+	if _, err := conn.ZAdd(keys[2], redis.Z{Member: id, Score: values[0]}); err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.Do("HSET", keys[0], id, args[2]); err != nil {
+		return nil, err
+	}
+
+	return int64(1), nil
+}
+
+func mockAckJob(conn *redis.RedisConnectionMock, keys []string, args []interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("%w: expected 1 script arg, got %d", redis.ErrScript, len(args))
+	}
+
+	id := fmt.Sprint(args[0])
+	removed, err := conn.ZRem(keys[1], id)
+
+	if err != nil || removed == 0 {
+		return int64(0), err
+	}
+
+	if _, err := conn.Do("HDEL", keys[0], id); err != nil {
+		return nil, err
+	}
+
+	return int64(1), nil
+}
+
+func mockListJobs(conn *redis.RedisConnectionMock, keys []string, args []interface{}) (interface{}, error) {
+	values, err := scriptNumbers(args, 1)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := conn.ZRange(keys[1], redis.ZRangeOptions{Start: 0, Stop: int(values[0]) - 1})
+
+	if err != nil {
+		return nil, err
+	}
+
+	reply := []interface{}{}
+
+	for _, id := range ids {
+		job, err := conn.Do("HGET", keys[0], id)
+
+		if err != nil {
+			return nil, err
+		}
+
+		reply = append(reply, id, job.Value())
+	}
+
+	return reply, nil
+}
+
+func mockJob(conn *redis.RedisConnectionMock, key string, id string) (Job, error) {
+	encoded, err := conn.Do("HGET", key, id)
+
+	if err != nil {
+		return Job{}, err
+	}
+
+	value, err := encoded.String()
+
+	if err != nil {
+		return Job{}, err
+	}
+
+	job := Job{}
+	err = json.Unmarshal([]byte(value), &job)
+
+	return job, err
+}
+
+// mockSetJob stores job and returns it encoded
+func mockSetJob(conn *redis.RedisConnectionMock, key string, id string, job Job) (string, error) {
+	encoded, err := json.Marshal(job)
+
+	if err != nil {
+		return "", err
+	}
+
+	_, err = conn.Do("HSET", key, id, string(encoded))
+	return string(encoded), err
+}
+
+// scriptNumbers parses the first count script args as numbers, the way lua
+// tonumber would
+func scriptNumbers(args []interface{}, count int) ([]float64, error) {
+	if len(args) < count {
+		return nil, fmt.Errorf("%w: expected %d script args, got %d", redis.ErrScript, count, len(args))
+	}
+
+	values := make([]float64, 0, count)
+
+	for _, arg := range args[:count] {
+		value, err := strconv.ParseFloat(fmt.Sprint(arg), 64)
+
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, value)
+	}
+
+	return values, nil
+}
@@ -0,0 +1,348 @@
+// Package queue is an at-least-once job queue stored in redis
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	redis "github.com/apinet/gcloud-redis"
+	redigo "github.com/gomodule/redigo/redis"
+)
+
+// Job is a queued payload. Attempts counts the deliveries so far, Error is
+// the cause of the last failure.
+type Job struct {
+	ID       string `json:"-"`
+	Payload  string `json:"payload"`
+	Priority int    `json:"priority"`
+	Attempts int    `json:"attempts"`
+	Error    string `json:"error,omitempty"`
+}
+
+type EnqueueOptions struct {
+	// Delay postpones the first delivery
+	Delay time.Duration
+	// Priority jobs are delivered first, highest first, then by enqueue order
+	Priority int
+}
+
+type Options struct {
+	// VisibilityTimeout is the time a dequeued job has to be acked or nacked
+	// before it's delivered again, 30 sec by default
+	VisibilityTimeout time.Duration
+	// MaxAttempts is the number of deliveries before a job is moved to the
+	// dead-letter queue, 5 by default
+	MaxAttempts int
+	// Backoff returns the delay before retrying a job nacked after attempts
+	// deliveries, exponential from 1 sec up to 10 min by default
+	Backoff func(attempts int) time.Duration
+}
+
+type Stats struct {
+	Ready      int
+	Delayed    int
+	Processing int
+	Dead       int
+}
+
+// Queue stores jobs in a hash, their ids moving between sorted sets of
+// ready, delayed, processing and dead jobs
+type Queue struct {
+	name    string
+	options Options
+}
+
+func New(name string, options Options) *Queue {
+	if options.VisibilityTimeout <= 0 {
+		options.VisibilityTimeout = 30 * time.Second
+	}
+
+	if options.MaxAttempts <= 0 {
+		options.MaxAttempts = 5
+	}
+
+	if options.Backoff == nil {
+		options.Backoff = redis.ExponentialBackoff(time.Second, 10*time.Minute)
+	}
+
+	return &Queue{
+		name:    name,
+		options: options,
+	}
+}
+
+// ready jobs are scored by -priority, their zero padded ids keeping the
+// enqueue order within a priority. Delayed, processing and dead jobs are
+// scored by the time in ms they are due, time out or died.
+
+var enqueueScript = redis.NewScript(4, `
+local id = string.format('%012d', redis.call('INCR', KEYS[4]))
+
+redis.call('HSET', KEYS[1], id, ARGV[1])
+
+if tonumber(ARGV[3]) > 0 then
+  redis.call('ZADD', KEYS[3], ARGV[3], id)
+else
+  redis.call('ZADD', KEYS[2], -tonumber(ARGV[2]), id)
+end
+
+return id
+`)
+
+var dequeueScript = redis.NewScript(5, `
+local now = tonumber(ARGV[1])
+
+for _, id in ipairs(redis.call('ZRANGEBYSCORE', KEYS[4], '-inf', now, 'LIMIT', 0, 100)) do
+  redis.call('ZREM', KEYS[4], id)
+  local job = cjson.decode(redis.call('HGET', KEYS[1], id))
+
+  if job.attempts >= tonumber(ARGV[3]) then
+    job.error = 'visibility timeout'
+    redis.call('HSET', KEYS[1], id, cjson.encode(job))
+    redis.call('ZADD', KEYS[5], now, id)
+  else
+    redis.call('ZADD', KEYS[2], -job.priority, id)
+  end
+end
+
+for _, id in ipairs(redis.call('ZRANGEBYSCORE', KEYS[3], '-inf', now, 'LIMIT', 0, 100)) do
+  redis.call('ZREM', KEYS[3], id)
+  local job = cjson.decode(redis.call('HGET', KEYS[1], id))
+  redis.call('ZADD', KEYS[2], -job.priority, id)
+end
+
+local id = redis.call('ZRANGE', KEYS[2], 0, 0)[1]
+
+if not id then
+  return false
+end
+
+redis.call('ZREM', KEYS[2], id)
+redis.call('ZADD', KEYS[4], now + tonumber(ARGV[2]), id)
+
+local job = cjson.decode(redis.call('HGET', KEYS[1], id))
+job.attempts = job.attempts + 1
+
+local encoded = cjson.encode(job)
+redis.call('HSET', KEYS[1], id, encoded)
+
+return {id, encoded}
+`)
+
+// moveJobScript moves a job id from a sorted set to another, replacing the
+// job. It returns 0 when the job isn't in the first set anymore.
+var moveJobScript = redis.NewScript(3, `
+if redis.call('ZREM', KEYS[2], ARGV[1]) == 0 then
+  return 0
+end
+
+redis.call('ZADD', KEYS[3], ARGV[2], ARGV[1])
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[3])
+
+return 1
+`)
+
+var ackJobScript = redis.NewScript(2, `
+if redis.call('ZREM', KEYS[2], ARGV[1]) == 0 then
+  return 0
+end
+
+redis.call('HDEL', KEYS[1], ARGV[1])
+
+return 1
+`)
+
+// listJobsScript returns the ids and jobs of the first jobs of a sorted set
+var listJobsScript = redis.NewScript(2, `
+local jobs = {}
+
+for _, id in ipairs(redis.call('ZRANGE', KEYS[2], 0, tonumber(ARGV[1]) - 1)) do
+  table.insert(jobs, id)
+  table.insert(jobs, redis.call('HGET', KEYS[1], id))
+end
+
+return jobs
+`)
+
+func (q *Queue) key(suffix string) string {
+	return q.name + ":" + suffix
+}
+
+// Enqueue adds a job and returns its id
+func (q *Queue) Enqueue(payload string, options EnqueueOptions, conn redis.RedisConnection) (string, error) {
+	encoded, err := json.Marshal(Job{Payload: payload, Priority: options.Priority})
+
+	if err != nil {
+		return "", err
+	}
+
+	due := int64(0)
+
+	if options.Delay > 0 {
+		now, err := conn.Time()
+
+		if err != nil {
+			return "", err
+		}
+
+		due = now.Add(options.Delay).UnixMilli()
+	}
+
+	keys := []string{q.key("jobs"), q.key("ready"), q.key("delayed"), q.key("seq")}
+	return redigo.String(conn.Eval(enqueueScript, keys, string(encoded), options.Priority, due))
+}
+
+// Dequeue claims the next job for the visibility timeout, first moving due
+// delayed jobs and timed out jobs back to the ready ones
+func (q *Queue) Dequeue(conn redis.RedisConnection) (Job, bool, error) {
+	now, err := conn.Time()
+
+	if err != nil {
+		return Job{}, false, err
+	}
+
+	keys := []string{q.key("jobs"), q.key("ready"), q.key("delayed"), q.key("processing"), q.key("dead")}
+	values, err := redigo.Strings(conn.Eval(dequeueScript, keys, now.UnixMilli(), q.options.VisibilityTimeout.Milliseconds(), q.options.MaxAttempts))
+
+	if err == redigo.ErrNil {
+		return Job{}, false, nil
+	}
+
+	if err != nil {
+		return Job{}, false, err
+	}
+
+	if len(values) != 2 {
+		return Job{}, false, fmt.Errorf("unexpected dequeue reply: %v", values)
+	}
+
+	job, err := decodeJob(values[0], values[1])
+	return job, err == nil, err
+}
+
+// Ack removes a processed job, not found if it timed out in between
+func (q *Queue) Ack(job Job, conn redis.RedisConnection) (bool, error) {
+	found, err := redigo.Int(conn.Eval(ackJobScript, []string{q.key("jobs"), q.key("processing")}, job.ID))
+	return found == 1, err
+}
+
+// Nack retries a failed job after the backoff, or moves it to the dead-letter
+// queue once it reached the max attempts. It's not found if it timed out in
+// between.
+func (q *Queue) Nack(job Job, cause error, conn redis.RedisConnection) (bool, error) {
+	now, err := conn.Time()
+
+	if err != nil {
+		return false, err
+	}
+
+	if cause != nil {
+		job.Error = cause.Error()
+	}
+
+	if job.Attempts >= q.options.MaxAttempts {
+		return q.move(job, q.key("processing"), q.key("dead"), now.UnixMilli(), conn)
+	}
+
+	due := now.Add(q.options.Backoff(job.Attempts)).UnixMilli()
+	return q.move(job, q.key("processing"), q.key("delayed"), due, conn)
+}
+
+// Process dequeues a job and runs handler on it, acking it on success and
+// nacking it with the handler error otherwise. It returns whether a job was
+// dequeued.
+func (q *Queue) Process(handler func(job Job) error, conn redis.RedisConnection) (bool, error) {
+	job, found, err := q.Dequeue(conn)
+
+	if err != nil || !found {
+		return false, err
+	}
+
+	if handlerErr := handler(job); handlerErr != nil {
+		if _, err := q.Nack(job, handlerErr, conn); err != nil {
+			return true, err
+		}
+
+		return true, handlerErr
+	}
+
+	_, err = q.Ack(job, conn)
+	return true, err
+}
+
+func (q *Queue) Stats(conn redis.RedisConnection) (Stats, error) {
+	pipe := conn.Pipeline()
+	ready := pipe.ZCard(q.key("ready"))
+	delayed := pipe.ZCard(q.key("delayed"))
+	processing := pipe.ZCard(q.key("processing"))
+	dead := pipe.ZCard(q.key("dead"))
+
+	if err := pipe.Exec(); err != nil {
+		return Stats{}, err
+	}
+
+	return Stats{
+		Ready:      ready.Value(),
+		Delayed:    delayed.Value(),
+		Processing: processing.Value(),
+		Dead:       dead.Value(),
+	}, nil
+}
+
+// DeadLetters returns up to n dead jobs, oldest first
+func (q *Queue) DeadLetters(n int, conn redis.RedisConnection) ([]Job, error) {
+	if n <= 0 {
+		return []Job{}, nil
+	}
+
+	values, err := redigo.Strings(conn.Eval(listJobsScript, []string{q.key("jobs"), q.key("dead")}, n))
+
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]Job, 0, len(values)/2)
+
+	for i := 0; i+1 < len(values); i += 2 {
+		job, err := decodeJob(values[i], values[i+1])
+
+		if err != nil {
+			return nil, err
+		}
+
+		jobs = append(jobs, job)
+	}
+
+	return jobs, nil
+}
+
+// Requeue moves a dead job back to the ready ones with its attempts reset
+func (q *Queue) Requeue(job Job, conn redis.RedisConnection) (bool, error) {
+	job.Attempts = 0
+	job.Error = ""
+
+	return q.move(job, q.key("dead"), q.key("ready"), int64(-job.Priority), conn)
+}
+
+func (q *Queue) move(job Job, from string, to string, score int64, conn redis.RedisConnection) (bool, error) {
+	encoded, err := json.Marshal(job)
+
+	if err != nil {
+		return false, err
+	}
+
+	found, err := redigo.Int(conn.Eval(moveJobScript, []string{q.key("jobs"), from, to}, job.ID, score, string(encoded)))
+	return found == 1, err
+}
+
+func decodeJob(id string, encoded string) (Job, error) {
+	job := Job{}
+
+	if err := json.Unmarshal([]byte(encoded), &job); err != nil {
+		return Job{}, err
+	}
+
+	job.ID = id
+	return job, nil
+}
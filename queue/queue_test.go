@@ -0,0 +1,153 @@
+package queue
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	redis "github.com/apinet/gcloud-redis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueue(t *testing.T) {
+
+	t.Run("priority and delay", func(t *testing.T) {
+		r := Mock(redis.MockRedis()).SetNow(100)
+		conn := r.Connection()
+		q := New("q", Options{})
+
+		q.Enqueue("low1", EnqueueOptions{}, conn)
+		q.Enqueue("later", EnqueueOptions{Delay: 10 * time.Second, Priority: 10}, conn)
+		q.Enqueue("high", EnqueueOptions{Priority: 5}, conn)
+		q.Enqueue("low2", EnqueueOptions{}, conn)
+
+		stats, err := q.Stats(conn)
+		assert.Nil(t, err, "must succeed")
+		assert.Equal(t, Stats{Ready: 3, Delayed: 1}, stats)
+
+		payloads := []string{}
+
+		for {
+			job, found, err := q.Dequeue(conn)
+			assert.Nil(t, err, "must succeed")
+
+			if !found {
+				break
+			}
+
+			payloads = append(payloads, job.Payload)
+			q.Ack(job, conn)
+		}
+
+		assert.Equal(t, []string{"high", "low1", "low2"}, payloads)
+
+		r.SetNow(110)
+		job, found, _ := q.Dequeue(conn)
+		assert.True(t, found, "delayed job must be due")
+		assert.Equal(t, "later", job.Payload)
+		assert.Equal(t, 1, job.Attempts)
+	})
+
+	t.Run("visibility timeout", func(t *testing.T) {
+		r := Mock(redis.MockRedis()).SetNow(100)
+		conn := r.Connection()
+		q := New("q", Options{VisibilityTimeout: 30 * time.Second, MaxAttempts: 2})
+
+		q.Enqueue("job", EnqueueOptions{}, conn)
+		first, _, _ := q.Dequeue(conn)
+
+		_, found, _ := q.Dequeue(conn)
+		assert.False(t, found, "job must be invisible")
+
+		r.SetNow(130)
+		second, found, _ := q.Dequeue(conn)
+		assert.True(t, found, "job must be delivered again")
+		assert.Equal(t, first.ID, second.ID)
+		assert.Equal(t, 2, second.Attempts)
+
+		acked, _ := q.Ack(first, conn)
+		assert.True(t, acked, "ack is per job, not per delivery")
+
+		q.Enqueue("crashing", EnqueueOptions{}, conn)
+		q.Dequeue(conn)
+		r.SetNow(160)
+		q.Dequeue(conn)
+		r.SetNow(190)
+		q.Dequeue(conn)
+
+		dead, _ := q.DeadLetters(10, conn)
+		assert.Len(t, dead, 1)
+		assert.Equal(t, "visibility timeout", dead[0].Error)
+	})
+
+	t.Run("retries and dead letters", func(t *testing.T) {
+		r := Mock(redis.MockRedis()).SetNow(100)
+		conn := r.Connection()
+		q := New("q", Options{MaxAttempts: 2, Backoff: redis.ExponentialBackoff(10*time.Second, time.Minute)})
+		failing := func(job Job) error {
+			return errors.New("boom")
+		}
+
+		q.Enqueue("job", EnqueueOptions{Priority: 1}, conn)
+
+		processed, err := q.Process(failing, conn)
+		assert.True(t, processed, "must dequeue")
+		assert.Equal(t, "boom", err.Error())
+
+		stats, _ := q.Stats(conn)
+		assert.Equal(t, Stats{Delayed: 1}, stats)
+
+		processed, _ = q.Process(failing, conn)
+		assert.False(t, processed, "must back off")
+
+		r.SetNow(110)
+		processed, _ = q.Process(failing, conn)
+		assert.True(t, processed, "must retry")
+
+		stats, _ = q.Stats(conn)
+		assert.Equal(t, Stats{Dead: 1}, stats)
+
+		dead, _ := q.DeadLetters(10, conn)
+		assert.Equal(t, []Job{{ID: dead[0].ID, Payload: "job", Priority: 1, Attempts: 2, Error: "boom"}}, dead)
+
+		requeued, _ := q.Requeue(dead[0], conn)
+		assert.True(t, requeued, "must requeue")
+
+		processed, err = q.Process(func(job Job) error {
+			assert.Equal(t, 1, job.Attempts)
+			return nil
+		}, conn)
+		assert.True(t, processed, "must process")
+		assert.Nil(t, err, "must succeed")
+
+		stats, _ = q.Stats(conn)
+		assert.Equal(t, Stats{}, stats)
+		assert.Equal(t, 1, r.GetNumKeys(), "only the id sequence must remain")
+	})
+
+	t.Run("wrong type", func(t *testing.T) {
+		conn := Mock(redis.MockRedis()).Connection()
+		q := New("q", Options{})
+
+		conn.SetString("q:jobs", "a", 0)
+		_, err := q.Enqueue("job", EnqueueOptions{}, conn)
+		assert.ErrorIs(t, err, redis.ErrWrongType)
+
+		conn.Delete("q:jobs")
+		conn.SetString("q:processing", "a", 0)
+		q.Enqueue("job", EnqueueOptions{}, conn)
+
+		_, _, err = q.Dequeue(conn)
+		assert.ErrorIs(t, err, redis.ErrWrongType)
+
+		_, err = q.Ack(Job{ID: "000000000001"}, conn)
+		assert.ErrorIs(t, err, redis.ErrWrongType)
+
+		_, err = q.Nack(Job{ID: "000000000001", Attempts: 1}, errors.New("failed"), conn)
+		assert.ErrorIs(t, err, redis.ErrWrongType)
+
+		conn.SetString("q:dead", "a", 0)
+		_, err = q.DeadLetters(10, conn)
+		assert.ErrorIs(t, err, redis.ErrWrongType)
+	})
+}
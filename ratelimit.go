@@ -0,0 +1,225 @@
+package redis
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// RateLimit is the outcome of a rate limiter call
+type RateLimit struct {
+	Allowed   bool
+	Remaining int
+	// ResetAfter is the time left before the limiter is back to full capacity
+	ResetAfter time.Duration
+}
+
+type RateLimiter interface {
+	Allow(key string, conn RedisConnection) (RateLimit, error)
+	AllowN(key string, n int, conn RedisConnection) (RateLimit, error)
+}
+
+// every script returns {allowed, remaining, reset after in ms} and only
+// consumes capacity when the call is allowed
+
+var fixedWindowScript = NewScript(1, `
+local key = KEYS[1]
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local n = tonumber(ARGV[3])
+
+local count = tonumber(redis.call('GET', key) or '0')
+local allowed = 0
+
+if count + n <= limit then
+  count = redis.call('INCRBY', key, n)
+  allowed = 1
+end
+
+local ttl = redis.call('PTTL', key)
+
+if ttl == -1 then
+  redis.call('PEXPIRE', key, window)
+  ttl = window
+end
+
+return {allowed, limit - count, math.max(ttl, 0)}
+`)
+
+var slidingWindowScript = NewScript(1, `
+local key = KEYS[1]
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local n = tonumber(ARGV[3])
+local now = tonumber(ARGV[4])
+local id = ARGV[5]
+
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+
+local count = redis.call('ZCARD', key)
+local allowed = 0
+
+if count + n <= limit then
+  for i = 1, n do
+    redis.call('ZADD', key, now, id .. ':' .. i)
+  end
+
+  count = count + n
+  allowed = 1
+end
+
+local reset = 0
+local newest = redis.call('ZRANGE', key, -1, -1, 'WITHSCORES')
+
+if newest[2] then
+  reset = tonumber(newest[2]) + window - now
+  redis.call('PEXPIRE', key, reset)
+end
+
+return {allowed, limit - count, reset}
+`)
+
+var tokenBucketScript = NewScript(1, `
+local key = KEYS[1]
+local rate = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local burst = tonumber(ARGV[3])
+local n = tonumber(ARGV[4])
+local now = tonumber(ARGV[5])
+
+if rate <= 0 then
+  return redis.error_reply('ERR rate must be positive')
+end
+
+local state = redis.call('HMGET', key, 'tokens', 'ts')
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate / period)
+
+local allowed = 0
+
+if tokens >= n then
+  tokens = tokens - n
+  allowed = 1
+end
+
+local reset = math.ceil((burst - tokens) * period / rate)
+
+if reset > 0 then
+  redis.call('HMSET', key, 'tokens', tokens, 'ts', now)
+  redis.call('PEXPIRE', key, reset)
+else
+  redis.call('DEL', key)
+end
+
+return {allowed, math.floor(tokens), reset}
+`)
+
+// NewFixedWindowLimiter allows limit calls per key in windows starting on
+// the first call
+func NewFixedWindowLimiter(limit int, window time.Duration) RateLimiter {
+	return &fixedWindowLimiter{
+		limit:  limit,
+		window: window,
+	}
+}
+
+type fixedWindowLimiter struct {
+	limit  int
+	window time.Duration
+}
+
+func (l *fixedWindowLimiter) Allow(key string, conn RedisConnection) (RateLimit, error) {
+	return l.AllowN(key, 1, conn)
+}
+
+func (l *fixedWindowLimiter) AllowN(key string, n int, conn RedisConnection) (RateLimit, error) {
+	return getRateLimit(conn.Eval(fixedWindowScript, []string{key}, l.limit, l.window.Milliseconds(), n))
+}
+
+// NewSlidingWindowLimiter allows limit calls per key over any window, logging
+// calls in a sorted set
+func NewSlidingWindowLimiter(limit int, window time.Duration) RateLimiter {
+	return &slidingWindowLimiter{
+		limit:  limit,
+		window: window,
+	}
+}
+
+type slidingWindowLimiter struct {
+	limit  int
+	window time.Duration
+}
+
+func (l *slidingWindowLimiter) Allow(key string, conn RedisConnection) (RateLimit, error) {
+	return l.AllowN(key, 1, conn)
+}
+
+func (l *slidingWindowLimiter) AllowN(key string, n int, conn RedisConnection) (RateLimit, error) {
+	now, err := conn.Time()
+
+	if err != nil {
+		return RateLimit{}, err
+	}
+
+	id := fmt.Sprintf("%d-%d", now.UnixNano(), rand.Int63())
+
+	return getRateLimit(conn.Eval(slidingWindowScript, []string{key}, l.limit, l.window.Milliseconds(), n, now.UnixMilli(), id))
+}
+
+// NewTokenBucketLimiter allows bursts of burst calls per key, refilled with
+// rate tokens every period
+func NewTokenBucketLimiter(rate int, period time.Duration, burst int) RateLimiter {
+	return &tokenBucketLimiter{
+		rate:   rate,
+		period: period,
+		burst:  burst,
+	}
+}
+
+type tokenBucketLimiter struct {
+	rate   int
+	period time.Duration
+	burst  int
+}
+
+func (l *tokenBucketLimiter) Allow(key string, conn RedisConnection) (RateLimit, error) {
+	return l.AllowN(key, 1, conn)
+}
+
+func (l *tokenBucketLimiter) AllowN(key string, n int, conn RedisConnection) (RateLimit, error) {
+	now, err := conn.Time()
+
+	if err != nil {
+		return RateLimit{}, err
+	}
+
+	return getRateLimit(conn.Eval(tokenBucketScript, []string{key}, l.rate, l.period.Milliseconds(), l.burst, n, now.UnixMilli()))
+}
+
+func getRateLimit(value interface{}, err error) (RateLimit, error) {
+	values, err := redis.Ints(value, err)
+
+	if err != nil {
+		return RateLimit{}, err
+	}
+
+	if len(values) != 3 {
+		return RateLimit{}, fmt.Errorf("unexpected rate limit reply: %v", values)
+	}
+
+	remaining := values[1]
+
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return RateLimit{
+		Allowed:    values[0] == 1,
+		Remaining:  remaining,
+		ResetAfter: time.Duration(values[2]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,200 @@
+package redis
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// go emulations of the rate limiter scripts, see ratelimit.go
+
+func mockFixedWindow(conn *RedisConnectionMock, keys []string, args []interface{}) (interface{}, error) {
+	values, err := mockScriptNumbers(args, 3)
+
+	if err != nil {
+		return nil, err
+	}
+
+	r := conn.redis
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	limit, window, n := int(values[0]), values[1], int(values[2])
+
+	count := 0
+	obj := r.lookup(keys[0])
+
+	if obj != nil {
+		if count, err = mockInt(obj.data); err != nil {
+			return nil, err
+		}
+	}
+
+	allowed := 0
+
+	if count+n <= limit {
+		count += n
+		allowed = 1
+
+		if obj == nil {
+			obj = &RedisMockObject{}
+			r.db[keys[0]] = obj
+		}
+
+		obj.data = mockValue(count)
+	}
+
+	ttl := 0
+
+	if obj != nil {
+		if obj.expiresAt == 0 {
+			obj.expiresAt = r.now + mockSeconds(window)
+		}
+
+		ttl = (obj.expiresAt - r.now) * 1000
+	}
+
+	return []interface{}{int64(allowed), int64(limit - count), int64(ttl)}, nil
+}
+
+func mockSlidingWindow(conn *RedisConnectionMock, keys []string, args []interface{}) (interface{}, error) {
+	if len(args) != 5 {
+		return nil, fmt.Errorf("%w: expected 5 script args, got %d", ErrScript, len(args))
+	}
+
+	values, err := mockScriptNumbers(args, 4)
+
+	if err != nil {
+		return nil, err
+	}
+
+	r := conn.redis
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	limit, window, n, now := int(values[0]), values[1], int(values[2]), values[3]
+
+	zset, err := r.getZSet(keys[0])
+
+	if err != nil {
+		return nil, err
+	}
+
+	for member, score := range zset {
+		if score <= now-window {
+			delete(zset, member)
+		}
+	}
+
+	count := len(zset)
+	allowed := 0
+
+	if count+n <= limit {
+		for i := 1; i <= n; i++ {
+			zset[fmt.Sprintf("%v:%d", args[4], i)] = now
+		}
+
+		count += n
+		allowed = 1
+	}
+
+	reset := 0.0
+
+	if len(zset) > 0 {
+		newest := math.Inf(-1)
+
+		for _, score := range zset {
+			newest = math.Max(newest, score)
+		}
+
+		reset = newest + window - now
+		r.db[keys[0]] = &RedisMockObject{
+			data:      zset,
+			expiresAt: r.now + mockSeconds(reset),
+		}
+	} else {
+		delete(r.db, keys[0])
+	}
+
+	return []interface{}{int64(allowed), int64(limit - count), int64(reset)}, nil
+}
+
+func mockTokenBucket(conn *RedisConnectionMock, keys []string, args []interface{}) (interface{}, error) {
+	values, err := mockScriptNumbers(args, 5)
+
+	if err != nil {
+		return nil, err
+	}
+
+	r := conn.redis
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rate, period, burst, n, now := values[0], values[1], values[2], values[3], values[4]
+
+	if rate <= 0 {
+		return nil, mockError("ERR rate must be positive")
+	}
+
+	tokens, ts := burst, now
+
+	if obj := r.lookup(keys[0]); obj != nil {
+		state, ok := obj.data.(map[string]string)
+
+		if !ok {
+			return nil, mockWrongType()
+		}
+
+		tokens, _ = strconv.ParseFloat(state["tokens"], 64)
+		ts, _ = strconv.ParseFloat(state["ts"], 64)
+	}
+
+	tokens = math.Min(burst, tokens+math.Max(0, now-ts)*rate/period)
+
+	allowed := 0
+
+	if tokens >= n {
+		tokens -= n
+		allowed = 1
+	}
+
+	reset := math.Ceil((burst - tokens) * period / rate)
+
+	if reset > 0 {
+		r.db[keys[0]] = &RedisMockObject{
+			data: map[string]string{
+				"tokens": strconv.FormatFloat(tokens, 'f', -1, 64),
+				"ts":     strconv.FormatFloat(now, 'f', -1, 64),
+			},
+			expiresAt: r.now + mockSeconds(reset),
+		}
+	} else {
+		delete(r.db, keys[0])
+	}
+
+	return []interface{}{int64(allowed), int64(math.Floor(tokens)), int64(reset)}, nil
+}
+
+// mockScriptNumbers parses the first count script args as numbers, the way
+// lua tonumber would
+func mockScriptNumbers(args []interface{}, count int) ([]float64, error) {
+	if len(args) < count {
+		return nil, fmt.Errorf("%w: expected %d script args, got %d", ErrScript, count, len(args))
+	}
+
+	values := make([]float64, 0, count)
+
+	for _, arg := range args[:count] {
+		value, err := strconv.ParseFloat(fmt.Sprint(arg), 64)
+
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
+// mockSeconds rounds a duration in ms up to the mock clock resolution
+func mockSeconds(ms float64) int {
+	return int(math.Ceil(ms / 1000))
+}
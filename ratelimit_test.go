@@ -0,0 +1,95 @@
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit(t *testing.T) {
+
+	t.Run("fixed window", func(t *testing.T) {
+		r := MockRedis()
+		conn := r.Connection()
+		limiter := NewFixedWindowLimiter(2, 10*time.Second)
+
+		res, err := limiter.Allow("rl", conn)
+		assert.Nil(t, err, "must succeed")
+		assert.Equal(t, RateLimit{Allowed: true, Remaining: 1, ResetAfter: 10 * time.Second}, res)
+
+		r.SetNow(4)
+		res, _ = limiter.Allow("rl", conn)
+		assert.Equal(t, RateLimit{Allowed: true, Remaining: 0, ResetAfter: 6 * time.Second}, res)
+
+		res, _ = limiter.Allow("rl", conn)
+		assert.False(t, res.Allowed, "limit must be reached")
+
+		ttl, _ := conn.GetExpire("rl")
+		assert.Equal(t, 6, ttl, "window must keep its ttl")
+
+		r.SetNow(10)
+		res, _ = limiter.Allow("rl", conn)
+		assert.Equal(t, RateLimit{Allowed: true, Remaining: 1, ResetAfter: 10 * time.Second}, res)
+	})
+
+	t.Run("sliding window", func(t *testing.T) {
+		r := MockRedis()
+		conn := r.Connection()
+		limiter := NewSlidingWindowLimiter(2, 10*time.Second)
+
+		limiter.Allow("rl", conn)
+		r.SetNow(5)
+
+		res, _ := limiter.Allow("rl", conn)
+		assert.Equal(t, RateLimit{Allowed: true, Remaining: 0, ResetAfter: 10 * time.Second}, res)
+
+		r.SetNow(9)
+		res, _ = limiter.Allow("rl", conn)
+		assert.False(t, res.Allowed, "limit must be reached")
+		assert.Equal(t, 6*time.Second, res.ResetAfter)
+
+		r.SetNow(10)
+		res, _ = limiter.Allow("rl", conn)
+		assert.Equal(t, RateLimit{Allowed: true, Remaining: 0, ResetAfter: 10 * time.Second}, res)
+	})
+
+	t.Run("token bucket", func(t *testing.T) {
+		r := MockRedis()
+		conn := r.Connection()
+		limiter := NewTokenBucketLimiter(1, 2*time.Second, 3)
+
+		res, _ := limiter.AllowN("rl", 3, conn)
+		assert.Equal(t, RateLimit{Allowed: true, Remaining: 0, ResetAfter: 6 * time.Second}, res)
+
+		res, _ = limiter.Allow("rl", conn)
+		assert.False(t, res.Allowed, "bucket must be empty")
+
+		r.SetNow(4)
+		res, _ = limiter.Allow("rl", conn)
+		assert.Equal(t, RateLimit{Allowed: true, Remaining: 1, ResetAfter: 4 * time.Second}, res)
+
+		r.SetNow(100)
+		res, _ = limiter.Allow("rl", conn)
+		assert.Equal(t, RateLimit{Allowed: true, Remaining: 2, ResetAfter: 2 * time.Second}, res)
+
+		_, err := NewTokenBucketLimiter(0, time.Second, 3).Allow("zero", conn)
+		assert.EqualError(t, err, "ERR rate must be positive")
+	})
+
+	t.Run("wrong type", func(t *testing.T) {
+		conn := MockRedis().Connection()
+		conn.LPush("rl", "a")
+
+		limiters := []RateLimiter{
+			NewFixedWindowLimiter(1, time.Second),
+			NewSlidingWindowLimiter(1, time.Second),
+			NewTokenBucketLimiter(1, time.Second, 1),
+		}
+
+		for _, limiter := range limiters {
+			_, err := limiter.Allow("rl", conn)
+			assert.ErrorIs(t, err, ErrWrongType)
+		}
+	})
+}
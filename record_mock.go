@@ -0,0 +1,162 @@
+package redis
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RecordedCommand is a command sent to the mock, every attempt of a retried
+// command being recorded
+type RecordedCommand struct {
+	Name string
+	Args []interface{}
+	// Connection numbers the connection sending the command, from 1 in the
+	// order they were opened
+	Connection int
+	// Pipeline numbers the pipeline of the command, from 1 in the order they
+	// were executed, 0 for a command sent alone
+	Pipeline int
+	// Time is when the command was sent, on the wall clock rather than the
+	// one of SetNow
+	Time time.Time
+}
+
+func (c RecordedCommand) String() string {
+	words := []string{c.Name}
+
+	for _, arg := range c.Args {
+		words = append(words, redisArg(arg))
+	}
+
+	return strings.Join(words, " ")
+}
+
+// matches tells whether c is name with args as first args, compared as
+// printed so that 60 matches "60"
+func (c RecordedCommand) matches(name string, args []interface{}) bool {
+	if !strings.EqualFold(c.Name, name) || len(args) > len(c.Args) {
+		return false
+	}
+
+	for i, arg := range args {
+		if redisArg(arg) != redisArg(c.Args[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// TestingT is the part of *testing.T used by the assertions of the mock
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+}
+
+// Commands returns the commands recorded, in order
+func (r *RedisMock) Commands() []RecordedCommand {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]RecordedCommand{}, r.recorded...)
+}
+
+// CommandCount returns the number of commands recorded named name
+func (r *RedisMock) CommandCount(name string) int {
+	num := 0
+
+	for _, cmd := range r.Commands() {
+		if strings.EqualFold(cmd.Name, name) {
+			num++
+		}
+	}
+
+	return num
+}
+
+// ResetCommands forgets the commands recorded, such as the ones setting up
+// a test
+func (r *RedisMock) ResetCommands() *RedisMock {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.recorded = nil
+	return r
+}
+
+// ExpectCommand fails t unless command name was recorded with args as its
+// first args, and returns the first one matching
+func (r *RedisMock) ExpectCommand(t TestingT, name string, args ...interface{}) RecordedCommand {
+	if h, ok := t.(interface{ Helper() }); ok {
+		h.Helper()
+	}
+
+	cmds := r.Commands()
+
+	for _, cmd := range cmds {
+		if cmd.matches(name, args) {
+			return cmd
+		}
+	}
+
+	expected := RecordedCommand{Name: strings.ToUpper(name), Args: args}
+	t.Errorf("expected command %s, recorded:\n%s", expected, formatCommands(cmds))
+
+	return RecordedCommand{}
+}
+
+// AssertCommandCount fails t unless num commands named name were recorded
+func (r *RedisMock) AssertCommandCount(t TestingT, name string, num int) {
+	if h, ok := t.(interface{ Helper() }); ok {
+		h.Helper()
+	}
+
+	if count := r.CommandCount(name); count != num {
+		t.Errorf("expected %d %s commands, recorded %d:\n%s", num, strings.ToUpper(name), count, formatCommands(r.Commands()))
+	}
+}
+
+// AssertNoCommands fails t if any command was recorded
+func (r *RedisMock) AssertNoCommands(t TestingT) {
+	if h, ok := t.(interface{ Helper() }); ok {
+		h.Helper()
+	}
+
+	if cmds := r.Commands(); len(cmds) > 0 {
+		t.Errorf("expected no commands, recorded:\n%s", formatCommands(cmds))
+	}
+}
+
+// record records command sent by conn in pipeline. Lock must be held.
+func (r *RedisMock) record(conn int, pipeline int, name string, args []interface{}) {
+	r.recorded = append(r.recorded, RecordedCommand{
+		Name:       name,
+		Args:       args,
+		Connection: conn,
+		Pipeline:   pipeline,
+		Time:       time.Now(),
+	})
+}
+
+// nextPipeline numbers a pipeline being executed
+func (r *RedisMock) nextPipeline() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.pipelines++
+	return r.pipelines
+}
+
+func formatCommands(cmds []RecordedCommand) string {
+	if len(cmds) == 0 {
+		return "  (none)"
+	}
+
+	lines := make([]string, 0, len(cmds))
+
+	for _, cmd := range cmds {
+		lines = append(lines, fmt.Sprintf("  %s (connection %d, pipeline %d)", cmd, cmd.Connection, cmd.Pipeline))
+	}
+
+	return strings.Join(lines, "\n")
+}
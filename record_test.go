@@ -0,0 +1,85 @@
+package redis
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingT records the failures of assertions
+type recordingT struct {
+	errors []string
+}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestRecord(t *testing.T) {
+
+	t.Run("commands", func(t *testing.T) {
+		r := MockRedis().SetNow(100)
+		r.AssertNoCommands(t)
+
+		conn := r.Connection()
+		conn.SetString("a", "value", 60)
+		conn.GetString("a")
+
+		pipe := conn.Pipeline()
+		pipe.IncrBy("b", 1)
+		pipe.IncrBy("c", 1)
+		pipe.Exec()
+
+		other := r.Connection()
+		other.Publish("channel", []byte("data"))
+
+		cmds := r.Commands()
+		assert.Equal(t, 5, len(cmds))
+		assert.Equal(t, "SETEX a 60 value", cmds[0].String())
+		assert.Equal(t, 1, cmds[0].Connection)
+		assert.Equal(t, 0, cmds[0].Pipeline, "single commands must not be in a pipeline")
+		assert.False(t, cmds[0].Time.IsZero())
+		assert.Equal(t, 1, cmds[2].Pipeline)
+		assert.Equal(t, 1, cmds[3].Pipeline, "must share the pipeline")
+		assert.Equal(t, 2, cmds[4].Connection)
+
+		setex := r.ExpectCommand(t, "setex", "a", 60)
+		assert.Equal(t, "value", setex.Args[2])
+		r.ExpectCommand(t, "PUBLISH", "channel", "data")
+		r.AssertCommandCount(t, "INCRBY", 2)
+		assert.Equal(t, 1, r.CommandCount("GET"))
+
+		r.ResetCommands()
+		r.AssertNoCommands(t)
+	})
+
+	t.Run("failures", func(t *testing.T) {
+		r := MockRedis()
+		conn := r.Connection()
+		conn.GetString("a")
+
+		rt := &recordingT{}
+		r.ExpectCommand(rt, "SETEX", "a")
+		r.AssertCommandCount(rt, "GET", 2)
+		r.AssertNoCommands(rt)
+
+		assert.Equal(t, 3, len(rt.errors))
+		assert.Equal(t, "expected command SETEX a, recorded:\n  GET a (connection 1, pipeline 0)", rt.errors[0])
+		assert.Contains(t, rt.errors[1], "expected 2 GET commands, recorded 1")
+	})
+
+	t.Run("scan and retries", func(t *testing.T) {
+		r := MockRedis().
+			With("a", "value", 0).
+			WithRetry(RetryPolicy{MaxAttempts: 3}).
+			FailsTransiently("GET", 1, nil)
+		conn := r.Connection()
+
+		conn.GetString("a")
+		conn.Scan(ScanOptions{Match: "a*"}).Next()
+
+		r.AssertCommandCount(t, "GET", 2)
+		r.ExpectCommand(t, "SCAN", "0", "MATCH", "a*")
+	})
+}
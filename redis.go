@@ -1,27 +1,39 @@
 package redis
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/gomodule/redigo/redis"
 )
 
-func NewRedis(address string, maxIdle int) Redis {
+func NewRedis(address string, maxIdle int, options ...Option) Redis {
+	o := newRedisOptions(options)
 	pool := &redis.Pool{
 		MaxIdle: maxIdle,
 		Dial: func() (redis.Conn, error) {
 			c, err := redis.Dial("tcp", address)
 			if err != nil {
-				return nil, fmt.Errorf("redis.Dial: %v", err)
+				o.logDial(address, err)
+				return nil, fmt.Errorf("redis.Dial: %w", err)
 			}
 			return c, err
 		},
 	}
 
-	return &RedisImpl{
-		pool: pool,
+	r := &RedisImpl{
+		pool:    pool,
+		options: o,
 	}
+
+	if o.metrics != nil {
+		o.metrics.ObservePool(r.poolStats)
+	}
+
+	return r
 }
 
 type Redis interface {
@@ -30,9 +42,14 @@ type Redis interface {
 
 type RedisConnection interface {
 	Exists(key string) (bool, error)
+	// Type returns the type of the value at key: "string", "list", "set",
+	// "zset", "hash" or "stream", or "none" if missing
+	Type(key string) (string, error)
 	SetExpire(key string, ttl int) error
 	GetExpire(key string) (int, error)
 	Delete(keys ...string) (int, error)
+	Unlink(keys ...string) (int, error)
+	Scan(options ScanOptions) *ScanIterator
 
 	GetString(key string) (string, bool, error)
 	SetString(key string, src string, ttl int) error
@@ -42,27 +59,97 @@ type RedisConnection interface {
 
 	IncrBy(key string, by int) (int, error)
 
+	LPush(key string, values ...string) (int, error)
+	RPush(key string, values ...string) (int, error)
+	LPop(key string) (string, bool, error)
+	RPop(key string) (string, bool, error)
+	LRange(key string, start int, stop int) ([]string, error)
+	LTrim(key string, start int, stop int) error
+	LLen(key string) (int, error)
+	// BLPop and BRPop wait up to timeout sec, 0 waits forever, for an item in
+	// one of keys and return the key popped from and the item
+	BLPop(timeout int, keys ...string) (string, string, bool, error)
+	BRPop(timeout int, keys ...string) (string, string, bool, error)
+
+	SAdd(key string, members ...string) (int, error)
+	SRem(key string, members ...string) (int, error)
+	SIsMember(key string, member string) (bool, error)
+	SMembers(key string) ([]string, error)
+	SInter(keys ...string) ([]string, error)
+	SUnion(keys ...string) ([]string, error)
+	SCard(key string) (int, error)
+
+	ZAdd(key string, members ...Z) (int, error)
+	ZIncrBy(key string, by float64, member string) (float64, error)
+	ZScore(key string, member string) (float64, bool, error)
+	ZRange(key string, options ZRangeOptions) ([]string, error)
+	ZRangeWithScores(key string, options ZRangeOptions) ([]Z, error)
+	ZRank(key string, member string) (int, bool, error)
+	ZRevRank(key string, member string) (int, bool, error)
+	ZRem(key string, members ...string) (int, error)
+	ZRemRangeByScore(key string, min string, max string) (int, error)
+	ZCard(key string) (int, error)
+
+	XAdd(stream string, values map[string]string) (string, error)
+	XLen(stream string) (int, error)
+	XGroupCreate(stream string, group string, start string, mkStream bool) error
+	XRead(options XReadOptions) ([]XStream, error)
+	XReadGroup(group string, consumer string, options XReadOptions) ([]XStream, error)
+	XAck(stream string, group string, ids ...string) (int, error)
+	XPending(stream string, group string, options XPendingOptions) ([]XPendingEntry, error)
+	XClaim(stream string, group string, consumer string, minIdle time.Duration, ids ...string) ([]XMessage, error)
+
+	PFAdd(key string, elements ...string) (bool, error)
+	PFCount(keys ...string) (int, error)
+	PFMerge(dest string, keys ...string) error
+
+	SetBit(key string, offset int, value bool) (bool, error)
+	GetBit(key string, offset int) (bool, error)
+	BitCount(key string) (int, error)
+	BitOp(op string, dest string, keys ...string) (int, error)
+	BitField(key string, ops ...BitFieldOp) ([]BitFieldValue, error)
+
+	GeoAdd(key string, locations ...GeoLocation) (int, error)
+	GeoDist(key string, member1 string, member2 string, unit string) (float64, bool, error)
+	GeoSearch(key string, options GeoSearchOptions) ([]GeoResult, error)
+
+	Time() (time.Time, error)
+	Eval(script *Script, keys []string, args ...interface{}) (interface{}, error)
+	Do(command string, args ...interface{}) (Reply, error)
+
+	// WithRetry returns a connection sharing this one, retrying as policy
+	// allows
+	WithRetry(policy RetryPolicy) RedisConnection
+	// WithContext returns a connection sharing this one, tracing its
+	// commands as children of the span in ctx
+	WithContext(ctx context.Context) RedisConnection
 	Pipeline() Pipeline
 
 	Subscribe(channel string) Subscribe
+	// Publish sends data on channel and returns the number of receivers
+	Publish(channel string, data []byte) (int, error)
 	Send(channel string, data []byte) error
 
 	Close()
 }
 
 type RedisImpl struct {
-	pool *redis.Pool
+	pool    *redis.Pool
+	options *redisOptions
 }
 
 func (r *RedisImpl) Connection() RedisConnection {
+	conn := withRetry(r.instrument(getConn(r.pool, r.options.breaker)), r.options.retry)
 
 	return &RedisConnectionImpl{
-		conn: r.pool.Get(),
+		conn:  conn,
+		redis: r,
 	}
 }
 
 type RedisConnectionImpl struct {
-	conn redis.Conn
+	conn  redis.Conn
+	redis *RedisImpl
 }
 
 func (c *RedisConnectionImpl) IncrBy(key string, by int) (int, error) {
@@ -79,6 +166,10 @@ func (c *RedisConnectionImpl) Exists(key string) (bool, error) {
 	return value > 0, nil
 }
 
+func (c *RedisConnectionImpl) Type(key string) (string, error) {
+	return redis.String(c.conn.Do("TYPE", key))
+}
+
 func (c *RedisConnectionImpl) GetString(key string) (string, bool, error) {
 	return getString(c.conn.Do("GET", key))
 }
@@ -106,10 +197,30 @@ func (c *RedisConnectionImpl) GetInt(key string) (int, bool, error) {
 	return getInt(c.conn.Do("GET", key))
 }
 
+func (c *RedisConnectionImpl) Time() (time.Time, error) {
+	return getTime(c.conn.Do("TIME"))
+}
+
+func (c *RedisConnectionImpl) Eval(script *Script, keys []string, args ...interface{}) (interface{}, error) {
+	if len(keys) != script.keyCount {
+		return nil, fmt.Errorf("%w: wrong number of keys", ErrScript)
+	}
+
+	return script.script.Do(c.conn, scriptArgs(keys, args)...)
+}
+
+func (c *RedisConnectionImpl) WithContext(ctx context.Context) RedisConnection {
+	return &RedisConnectionImpl{
+		conn:  withContext(c.conn, ctx),
+		redis: c.redis,
+	}
+}
+
 func (c *RedisConnectionImpl) Pipeline() Pipeline {
 	return &PipelineImpl{
-		conn: c.conn,
-		cmds: make([]interface{}, 0, 30),
+		conn:  c.conn,
+		redis: c.redis,
+		cmds:  make([]interface{}, 0, 30),
 	}
 }
 
@@ -123,12 +234,47 @@ func (c *RedisConnectionImpl) Delete(keys ...string) (int, error) {
 	return redis.Int(c.conn.Do("DEL", iKeys...))
 }
 
+func (c *RedisConnectionImpl) Unlink(keys ...string) (int, error) {
+	iKeys := make([]interface{}, 0, len(keys))
+
+	for _, key := range keys {
+		iKeys = append(iKeys, key)
+	}
+
+	return redis.Int(c.conn.Do("UNLINK", iKeys...))
+}
+
+func (c *RedisConnectionImpl) Scan(options ScanOptions) *ScanIterator {
+	return newScanIterator(func(cursor string) (string, []string, error) {
+		values, err := redis.Values(c.conn.Do("SCAN", options.args(cursor)...))
+
+		if err != nil {
+			return "", nil, err
+		}
+
+		if len(values) != 2 {
+			return "", nil, errors.New("unexpected SCAN reply")
+		}
+
+		next, err := redis.String(values[0], nil)
+
+		if err != nil {
+			return "", nil, err
+		}
+
+		keys, err := redis.Strings(values[1], nil)
+		return next, keys, err
+	})
+}
+
 func (c *RedisConnectionImpl) Close() {
 	c.conn.Close()
 }
 
 func (c *RedisConnectionImpl) Subscribe(channel string) Subscribe {
-	conn := redis.PubSubConn{Conn: c.conn}
+	// pub/sub replies are received apart from their commands, which hooks
+	// can't follow
+	conn := redis.PubSubConn{Conn: uninstrumented(c.conn)}
 	conn.Subscribe(channel)
 
 	return &SubscribeImpl{
@@ -136,16 +282,26 @@ func (c *RedisConnectionImpl) Subscribe(channel string) Subscribe {
 	}
 }
 
+func (c *RedisConnectionImpl) Publish(channel string, data []byte) (int, error) {
+	return redis.Int(c.conn.Do("PUBLISH", channel, data))
+}
+
+// Send is Publish without the number of receivers
 func (c *RedisConnectionImpl) Send(channel string, data []byte) error {
-	_, err := c.conn.Do("PUBLISH", channel, data)
+	_, err := c.Publish(channel, data)
 	return err
 }
 
+// Pipeline queues the commands of RedisConnection but the iterating,
+// subscribing and connection ones, sending them on Exec
 type Pipeline interface {
+	Exists(key string) *ExistsCmd
+	Type(key string) *TypeCmd
+
 	GetInt(key string) *GetIntCmd
 	SetInt(key string, value int, ttl int)
 
-	SetExpire(key string, ttl int)
+	SetExpire(key string, ttl int) *SetExpireCmd
 	GetExpire(key string) *GetExpireCmd
 
 	IncrBy(key string, by int) *IncrByCmd
@@ -153,13 +309,74 @@ type Pipeline interface {
 	GetString(key string) *GetStringCmd
 	SetString(key string, value string, ttl int)
 
-	Delete(key string) *DeleteCmd
+	Delete(keys ...string) *DeleteCmd
+	Unlink(keys ...string) *DeleteCmd
+
+	LPush(key string, values ...string) *PushCmd
+	RPush(key string, values ...string) *PushCmd
+	LPop(key string) *PopCmd
+	RPop(key string) *PopCmd
+	LRange(key string, start int, stop int) *LRangeCmd
+	LTrim(key string, start int, stop int)
+	LLen(key string) *LLenCmd
+	BLPop(timeout int, keys ...string) *BlockingPopCmd
+	BRPop(timeout int, keys ...string) *BlockingPopCmd
+
+	SAdd(key string, members ...string) *SAddCmd
+	SRem(key string, members ...string) *SRemCmd
+	SIsMember(key string, member string) *SIsMemberCmd
+	SMembers(key string) *SetMembersCmd
+	SInter(keys ...string) *SetMembersCmd
+	SUnion(keys ...string) *SetMembersCmd
+	SCard(key string) *SCardCmd
+
+	ZAdd(key string, members ...Z) *ZAddCmd
+	ZIncrBy(key string, by float64, member string) *ZIncrByCmd
+	ZScore(key string, member string) *ZScoreCmd
+	ZRange(key string, options ZRangeOptions) *ZRangeCmd
+	ZRangeWithScores(key string, options ZRangeOptions) *ZRangeWithScoresCmd
+	ZRank(key string, member string) *ZRankCmd
+	ZRevRank(key string, member string) *ZRankCmd
+	ZRem(key string, members ...string) *ZRemCmd
+	ZRemRangeByScore(key string, min string, max string) *ZRemRangeByScoreCmd
+	ZCard(key string) *ZCardCmd
+
+	XAdd(stream string, values map[string]string) *XAddCmd
+	XLen(stream string) *XLenCmd
+	XGroupCreate(stream string, group string, start string, mkStream bool) *XGroupCreateCmd
+	XRead(options XReadOptions) *XReadCmd
+	XReadGroup(group string, consumer string, options XReadOptions) *XReadCmd
+	XAck(stream string, group string, ids ...string) *XAckCmd
+	XPending(stream string, group string, options XPendingOptions) *XPendingCmd
+	XClaim(stream string, group string, consumer string, minIdle time.Duration, ids ...string) *XClaimCmd
+
+	PFAdd(key string, elements ...string) *PFAddCmd
+	PFCount(keys ...string) *PFCountCmd
+	PFMerge(dest string, keys ...string) *PFMergeCmd
+
+	SetBit(key string, offset int, value bool) *SetBitCmd
+	GetBit(key string, offset int) *GetBitCmd
+	BitCount(key string) *BitCountCmd
+	BitOp(op string, dest string, keys ...string) *BitOpCmd
+	BitField(key string, ops ...BitFieldOp) *BitFieldCmd
+
+	GeoAdd(key string, locations ...GeoLocation) *GeoAddCmd
+	GeoDist(key string, member1 string, member2 string, unit string) *GeoDistCmd
+	GeoSearch(key string, options GeoSearchOptions) *GeoSearchCmd
+
+	Time() *TimeCmd
+	Eval(script *Script, keys []string, args ...interface{}) *EvalCmd
+	Do(command string, args ...interface{}) *DoCmd
+
+	Publish(channel string, data []byte) *PublishCmd
+
 	Exec() error
 }
 
 type PipelineImpl struct {
-	cmds []interface{}
-	conn redis.Conn
+	cmds  []interface{}
+	conn  redis.Conn
+	redis *RedisImpl
 }
 
 func (p *PipelineImpl) GetInt(key string) *GetIntCmd {
@@ -183,13 +400,14 @@ func (p *PipelineImpl) SetInt(key string, value int, ttl int) {
 	p.cmds = append(p.cmds, &cmd)
 }
 
-func (p *PipelineImpl) SetExpire(key string, ttl int) {
+func (p *PipelineImpl) SetExpire(key string, ttl int) *SetExpireCmd {
 	cmd := SetExpireCmd{
 		key:   key,
 		value: ttl,
 	}
 
 	p.cmds = append(p.cmds, &cmd)
+	return &cmd
 }
 
 func (p *PipelineImpl) GetExpire(key string) *GetExpireCmd {
@@ -233,31 +451,186 @@ func (p *PipelineImpl) SetString(key string, value string, ttl int) {
 	p.cmds = append(p.cmds, &cmd)
 }
 
-func (p *PipelineImpl) Delete(key string) *DeleteCmd {
+func (p *PipelineImpl) Exists(key string) *ExistsCmd {
+	cmd := ExistsCmd{
+		key: key,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+func (p *PipelineImpl) Type(key string) *TypeCmd {
+	cmd := TypeCmd{
+		key: key,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+func (p *PipelineImpl) Delete(keys ...string) *DeleteCmd {
 	cmd := DeleteCmd{
-		key:   key,
-		found: false,
+		keys: keys,
 	}
 
 	p.cmds = append(p.cmds, &cmd)
 	return &cmd
 }
 
-// Exec send and receive registered commands and set corresponding values
-func (p *PipelineImpl) Exec() error {
-	if err := sendCmds(p.conn, p.cmds); err != nil {
-		return err
+func (p *PipelineImpl) Unlink(keys ...string) *DeleteCmd {
+	cmd := DeleteCmd{
+		keys:   keys,
+		unlink: true,
 	}
 
-	if err := p.conn.Flush(); err != nil {
-		return err
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+func (p *PipelineImpl) Time() *TimeCmd {
+	cmd := TimeCmd{}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+// Eval runs script with EVAL, as EVALSHA can't fall back within a pipeline
+func (p *PipelineImpl) Eval(script *Script, keys []string, args ...interface{}) *EvalCmd {
+	cmd := EvalCmd{
+		script: script,
+		keys:   keys,
+		args:   args,
 	}
 
-	if err := receiveCmds(p.conn, p.cmds); err != nil {
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+func (p *PipelineImpl) Publish(channel string, data []byte) *PublishCmd {
+	cmd := PublishCmd{
+		channel: channel,
+		data:    data,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+// Exec send and receive registered commands and set corresponding values,
+// in chunks when configured with WithPipelineChunks
+func (p *PipelineImpl) Exec() (err error) {
+	done := instrumentPipeline(p.conn, len(p.cmds))
+	defer func() { done(err) }()
+
+	options := &redisOptions{parallelism: 1}
+
+	if p.redis != nil {
+		options = p.redis.options
+	}
+
+	chunks, err := chunkCmds(p.cmds, options.maxCommands, options.maxBytes)
+
+	if err != nil {
 		return err
 	}
 
-	return nil
+	if options.parallelism <= 1 || len(chunks) <= 1 {
+		offset := 0
+
+		for _, chunk := range chunks {
+			if err := p.execChunk(p.conn, chunk, offset); err != nil {
+				return err
+			}
+
+			offset += len(chunk)
+		}
+
+		return nil
+	}
+
+	return p.execParallel(chunks, options.parallelism)
+}
+
+// execParallel runs the first chunk on the pipeline connection and the
+// others on pooled connections, up to parallelism at once
+func (p *PipelineImpl) execParallel(chunks [][]interface{}, parallelism int) error {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+
+	slots := make(chan struct{}, parallelism)
+	offset := 0
+
+	for i, chunk := range chunks {
+		slots <- struct{}{}
+		wg.Add(1)
+
+		go func(i int, chunk []interface{}, offset int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			conn := p.conn
+
+			if i > 0 {
+				conn = p.redis.instrument(getConn(p.redis.pool, p.redis.options.breaker))
+				defer conn.Close()
+			}
+
+			if err := p.execChunk(conn, chunk, offset); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}(i, chunk, offset)
+
+		offset += len(chunk)
+	}
+
+	wg.Wait()
+	return firstErr
+}
+
+// execChunk runs chunk, starting at offset in the pipeline, on conn, guarded
+// by the circuit breaker and retried as the policy of the pipeline connection
+// allows
+func (p *PipelineImpl) execChunk(conn redis.Conn, chunk []interface{}, offset int) error {
+	c := instrumented(conn)
+	exec := func() error {
+		return c.options.breaker.call(func() error {
+			return execCmds(conn, chunk)
+		})
+	}
+
+	var err error
+
+	if retry, ok := retryOf(p.conn); ok {
+		err = retry.policy.do(contextOf(p.conn), idempotentCmds(chunk), exec, c.reconnect)
+	} else {
+		err = exec()
+	}
+
+	var pipelineErr *PipelineError
+
+	if errors.As(err, &pipelineErr) {
+		pipelineErr.Index += offset
+	}
+
+	return err
+}
+
+func execCmds(conn redis.Conn, cmds []interface{}) error {
+	if err := sendCmds(conn, cmds); err != nil {
+		return commandError(err)
+	}
+
+	if err := conn.Flush(); err != nil {
+		return commandError(err)
+	}
+
+	return receiveCmds(conn, cmds)
 }
 
 // each *Cmd must have input and output field
@@ -298,13 +671,60 @@ func (g *GetExpireCmd) Value() int {
 	return g.value
 }
 
-type DeleteCmd struct {
+type ExistsCmd struct {
 	key   string
-	found bool
+	value bool
+}
+
+func (e *ExistsCmd) Value() bool {
+	return e.value
+}
+
+type TypeCmd struct {
+	key   string
+	value string
+}
+
+func (t *TypeCmd) Value() string {
+	return t.value
 }
 
+// DeleteCmd is a DEL or UNLINK, its value being the number of keys removed
+type DeleteCmd struct {
+	keys   []string
+	unlink bool
+	value  int
+}
+
+func (d *DeleteCmd) Value() int {
+	return d.value
+}
+
+// Found tells whether at least one key was removed
 func (d *DeleteCmd) Found() bool {
-	return d.found
+	return d.value > 0
+}
+
+type EvalCmd struct {
+	script *Script
+	keys   []string
+	args   []interface{}
+	value  interface{}
+}
+
+func (e *EvalCmd) Value() interface{} {
+	return e.value
+}
+
+// PublishCmd value is the number of receivers
+type PublishCmd struct {
+	channel string
+	data    []byte
+	value   int
+}
+
+func (p *PublishCmd) Value() int {
+	return p.value
 }
 
 type SetStringCmd struct {
@@ -322,6 +742,11 @@ type SetIntCmd struct {
 type SetExpireCmd struct {
 	key   string
 	value int
+	found bool
+}
+
+func (s *SetExpireCmd) Found() bool {
+	return s.found
 }
 
 type IncrByCmd struct {
@@ -334,6 +759,14 @@ func (i *IncrByCmd) Value() int {
 	return i.value
 }
 
+type TimeCmd struct {
+	value time.Time
+}
+
+func (t *TimeCmd) Value() time.Time {
+	return t.value
+}
+
 func sendCmds(conn redis.Conn, cmds []interface{}) error {
 	for _, cmd := range cmds {
 		switch cmd := cmd.(type) {
@@ -370,85 +803,710 @@ func sendCmds(conn redis.Conn, cmds []interface{}) error {
 			if err := conn.Send("EXPIRE", cmd.key, cmd.value); err != nil {
 				return err
 			}
+		case *ExistsCmd:
+			if err := conn.Send("EXISTS", cmd.key); err != nil {
+				return err
+			}
+
+		case *TypeCmd:
+			if err := conn.Send("TYPE", cmd.key); err != nil {
+				return err
+			}
+
 		case *DeleteCmd:
-			if err := conn.Send("DEL", cmd.key); err != nil {
+			command := "DEL"
+			if cmd.unlink {
+				command = "UNLINK"
+			}
+
+			if err := conn.Send(command, stringArgs(cmd.keys)...); err != nil {
 				return err
 			}
 
-		default:
-			return errors.New("unsupported command")
-		}
-	}
+		case *PushCmd:
+			command := "RPUSH"
+			if cmd.left {
+				command = "LPUSH"
+			}
 
-	return nil
-}
+			if err := conn.Send(command, keyArgs(cmd.key, cmd.values)...); err != nil {
+				return err
+			}
 
-func receiveCmds(conn redis.Conn, cmds []interface{}) error {
-	for _, cmd := range cmds {
-		switch cmd := cmd.(type) {
-		case *GetIntCmd:
-			value, found, err := getInt(conn.Receive())
+		case *PopCmd:
+			command := "RPOP"
+			if cmd.left {
+				command = "LPOP"
+			}
 
-			if err != nil {
+			if err := conn.Send(command, cmd.key); err != nil {
+				return err
+			}
+
+		case *LRangeCmd:
+			if err := conn.Send("LRANGE", cmd.key, cmd.start, cmd.stop); err != nil {
 				return err
 			}
 
-			cmd.found = found
-			cmd.value = value
+		case *LTrimCmd:
+			if err := conn.Send("LTRIM", cmd.key, cmd.start, cmd.stop); err != nil {
+				return err
+			}
 
-		case *SetIntCmd:
-			if _, err := conn.Receive(); err != nil {
+		case *LLenCmd:
+			if err := conn.Send("LLEN", cmd.key); err != nil {
 				return err
 			}
 
-		case *IncrByCmd:
-			value, found, err := getInt(conn.Receive())
+		case *BlockingPopCmd:
+			command := "BRPOP"
+			if cmd.left {
+				command = "BLPOP"
+			}
 
-			if err != nil || !found {
+			if err := conn.Send(command, blockingArgs(cmd.keys, cmd.timeout)...); err != nil {
 				return err
 			}
 
-			cmd.value = value
+		case *SAddCmd:
+			if err := conn.Send("SADD", keyArgs(cmd.key, cmd.members)...); err != nil {
+				return err
+			}
 
-		case *GetStringCmd:
-			value, found, err := getString(conn.Receive())
+		case *SRemCmd:
+			if err := conn.Send("SREM", keyArgs(cmd.key, cmd.members)...); err != nil {
+				return err
+			}
 
-			if err != nil {
+		case *SIsMemberCmd:
+			if err := conn.Send("SISMEMBER", cmd.key, cmd.member); err != nil {
 				return err
 			}
 
-			cmd.found = found
-			cmd.value = value
+		case *SetMembersCmd:
+			if err := conn.Send(cmd.command, stringArgs(cmd.keys)...); err != nil {
+				return err
+			}
 
-		case *SetStringCmd:
-			if _, err := conn.Receive(); err != nil {
+		case *SCardCmd:
+			if err := conn.Send("SCARD", cmd.key); err != nil {
 				return err
 			}
 
-		case *SetExpireCmd:
-			if _, err := conn.Receive(); err != nil {
+		case *ZAddCmd:
+			if err := conn.Send("ZADD", zArgs(cmd.key, cmd.members)...); err != nil {
 				return err
 			}
 
-		case *GetExpireCmd:
-			if _, err := getTTL(conn.Receive()); err != nil {
+		case *ZIncrByCmd:
+			if err := conn.Send("ZINCRBY", cmd.key, cmd.by, cmd.member); err != nil {
 				return err
 			}
 
-		case *DeleteCmd:
-			num, err := redis.Int(conn.Receive())
-			if err != nil {
+		case *ZScoreCmd:
+			if err := conn.Send("ZSCORE", cmd.key, cmd.member); err != nil {
+				return err
+			}
+
+		case *ZRangeCmd:
+			if err := conn.Send("ZRANGE", cmd.options.args(cmd.key, false)...); err != nil {
+				return err
+			}
+
+		case *ZRangeWithScoresCmd:
+			if err := conn.Send("ZRANGE", cmd.options.args(cmd.key, true)...); err != nil {
+				return err
+			}
+
+		case *ZRankCmd:
+			command := "ZRANK"
+			if cmd.rev {
+				command = "ZREVRANK"
+			}
+
+			if err := conn.Send(command, cmd.key, cmd.member); err != nil {
+				return err
+			}
+
+		case *ZRemCmd:
+			if err := conn.Send("ZREM", keyArgs(cmd.key, cmd.members)...); err != nil {
+				return err
+			}
+
+		case *ZRemRangeByScoreCmd:
+			if err := conn.Send("ZREMRANGEBYSCORE", cmd.key, cmd.min, cmd.max); err != nil {
+				return err
+			}
+
+		case *ZCardCmd:
+			if err := conn.Send("ZCARD", cmd.key); err != nil {
+				return err
+			}
+
+		case *XAddCmd:
+			if err := conn.Send("XADD", xAddArgs(cmd.stream, cmd.values)...); err != nil {
+				return err
+			}
+
+		case *XLenCmd:
+			if err := conn.Send("XLEN", cmd.stream); err != nil {
+				return err
+			}
+
+		case *XGroupCreateCmd:
+			if err := conn.Send("XGROUP", xGroupCreateArgs(cmd.stream, cmd.group, cmd.start, cmd.mkStream)...); err != nil {
+				return err
+			}
+
+		case *XReadCmd:
+			command, args := "XREAD", cmd.options.args()
+			if len(cmd.group) > 0 {
+				command, args = "XREADGROUP", cmd.options.groupArgs(cmd.group, cmd.consumer)
+			}
+
+			if err := conn.Send(command, args...); err != nil {
+				return err
+			}
+
+		case *XAckCmd:
+			if err := conn.Send("XACK", xAckArgs(cmd.stream, cmd.group, cmd.ids)...); err != nil {
+				return err
+			}
+
+		case *XPendingCmd:
+			if err := conn.Send("XPENDING", cmd.options.args(cmd.stream, cmd.group)...); err != nil {
+				return err
+			}
+
+		case *XClaimCmd:
+			if err := conn.Send("XCLAIM", xClaimArgs(cmd.stream, cmd.group, cmd.consumer, cmd.minIdle, cmd.ids)...); err != nil {
+				return err
+			}
+
+		case *PFAddCmd:
+			if err := conn.Send("PFADD", keyArgs(cmd.key, cmd.elements)...); err != nil {
+				return err
+			}
+
+		case *PFCountCmd:
+			if err := conn.Send("PFCOUNT", stringArgs(cmd.keys)...); err != nil {
+				return err
+			}
+
+		case *PFMergeCmd:
+			if err := conn.Send("PFMERGE", keyArgs(cmd.dest, cmd.keys)...); err != nil {
+				return err
+			}
+
+		case *SetBitCmd:
+			if err := conn.Send("SETBIT", cmd.key, cmd.offset, bitArg(cmd.bit)); err != nil {
+				return err
+			}
+
+		case *GetBitCmd:
+			if err := conn.Send("GETBIT", cmd.key, cmd.offset); err != nil {
+				return err
+			}
+
+		case *BitCountCmd:
+			if err := conn.Send("BITCOUNT", cmd.key); err != nil {
+				return err
+			}
+
+		case *BitOpCmd:
+			if err := conn.Send("BITOP", append([]interface{}{cmd.op}, keyArgs(cmd.dest, cmd.keys)...)...); err != nil {
+				return err
+			}
+
+		case *BitFieldCmd:
+			if err := conn.Send("BITFIELD", bitFieldArgs(cmd.key, cmd.ops)...); err != nil {
 				return err
 			}
 
-			if num > 0 {
-				cmd.found = true
+		case *GeoAddCmd:
+			if err := conn.Send("GEOADD", geoAddArgs(cmd.key, cmd.locations)...); err != nil {
+				return err
+			}
+
+		case *GeoDistCmd:
+			if err := conn.Send("GEODIST", geoDistArgs(cmd.key, cmd.member1, cmd.member2, cmd.unit)...); err != nil {
+				return err
+			}
+
+		case *GeoSearchCmd:
+			if err := conn.Send("GEOSEARCH", cmd.options.args(cmd.key)...); err != nil {
+				return err
+			}
+
+		case *TimeCmd:
+			if err := conn.Send("TIME"); err != nil {
+				return err
+			}
+
+		case *EvalCmd:
+			if len(cmd.keys) != cmd.script.keyCount {
+				return fmt.Errorf("%w: wrong number of keys", ErrScript)
+			}
+
+			if err := cmd.script.script.Send(conn, scriptArgs(cmd.keys, cmd.args)...); err != nil {
+				return err
+			}
+
+		case *PublishCmd:
+			if err := conn.Send("PUBLISH", cmd.channel, cmd.data); err != nil {
+				return err
+			}
+
+		case *DoCmd:
+			if err := conn.Send(cmd.command, cmd.args...); err != nil {
+				return err
 			}
-			cmd.found = false
 
 		default:
-			return errors.New("unsupported command")
+			return ErrUnsupportedCommand
+		}
+	}
+
+	return nil
+}
+
+// receiveCmds sets the values of cmds, returning the first error as a
+// PipelineError. Replies are still read after an error, unless the connection
+// broke.
+func receiveCmds(conn redis.Conn, cmds []interface{}) error {
+	var firstErr error
+
+	for i, cmd := range cmds {
+		if err := receiveCmd(conn, cmd); err != nil {
+			if firstErr == nil {
+				firstErr = pipelineError(i, cmd, err)
+			}
+
+			if conn.Err() != nil {
+				break
+			}
+		}
+	}
+
+	return firstErr
+}
+
+func receiveCmd(conn redis.Conn, cmd interface{}) error {
+	switch cmd := cmd.(type) {
+	case *GetIntCmd:
+		value, found, err := getInt(conn.Receive())
+
+		if err != nil {
+			return err
+		}
+
+		cmd.found = found
+		cmd.value = value
+
+	case *SetIntCmd:
+		if _, err := conn.Receive(); err != nil {
+			return err
+		}
+
+	case *IncrByCmd:
+		value, found, err := getInt(conn.Receive())
+
+		if err != nil || !found {
+			return err
+		}
+
+		cmd.value = value
+
+	case *GetStringCmd:
+		value, found, err := getString(conn.Receive())
+
+		if err != nil {
+			return err
+		}
+
+		cmd.found = found
+		cmd.value = value
+
+	case *SetStringCmd:
+		if _, err := conn.Receive(); err != nil {
+			return err
+		}
+
+	case *SetExpireCmd:
+		num, err := redis.Int(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.found = num > 0
+
+	case *GetExpireCmd:
+		value, err := getTTL(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *ExistsCmd:
+		num, err := redis.Int(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.value = num > 0
+
+	case *TypeCmd:
+		value, err := redis.String(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *DeleteCmd:
+		value, err := redis.Int(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *PushCmd:
+		value, err := redis.Int(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *PopCmd:
+		value, found, err := getString(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+		cmd.found = found
+
+	case *LRangeCmd:
+		value, err := redis.Strings(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *LTrimCmd:
+		if _, err := conn.Receive(); err != nil {
+			return err
+		}
+
+	case *LLenCmd:
+		value, err := redis.Int(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *BlockingPopCmd:
+		key, value, found, err := getBlockingPop(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.key = key
+		cmd.value = value
+		cmd.found = found
+
+	case *SAddCmd:
+		value, err := redis.Int(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *SRemCmd:
+		value, err := redis.Int(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *SIsMemberCmd:
+		value, err := redis.Bool(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *SetMembersCmd:
+		value, err := redis.Strings(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *SCardCmd:
+		value, err := redis.Int(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *ZAddCmd:
+		value, err := redis.Int(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *ZIncrByCmd:
+		value, err := redis.Float64(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *ZScoreCmd:
+		value, found, err := getFloat(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+		cmd.found = found
+
+	case *ZRangeCmd:
+		value, err := redis.Strings(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *ZRangeWithScoresCmd:
+		value, err := getZs(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *ZRankCmd:
+		value, found, err := getInt(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+		cmd.found = found
+
+	case *ZRemCmd:
+		value, err := redis.Int(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *ZRemRangeByScoreCmd:
+		value, err := redis.Int(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *ZCardCmd:
+		value, err := redis.Int(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *XAddCmd:
+		value, err := redis.String(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *XLenCmd:
+		value, err := redis.Int(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *XGroupCreateCmd:
+		if _, err := conn.Receive(); err != nil {
+			return err
+		}
+
+	case *XReadCmd:
+		value, err := getXStreams(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *XAckCmd:
+		value, err := redis.Int(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *XPendingCmd:
+		value, err := getXPending(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *XClaimCmd:
+		value, err := getXMessages(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *PFAddCmd:
+		value, err := redis.Bool(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *PFCountCmd:
+		value, err := redis.Int(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *PFMergeCmd:
+		if _, err := conn.Receive(); err != nil {
+			return err
+		}
+
+	case *SetBitCmd:
+		value, err := redis.Bool(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *GetBitCmd:
+		value, err := redis.Bool(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *BitCountCmd:
+		value, err := redis.Int(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *BitOpCmd:
+		value, err := redis.Int(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *BitFieldCmd:
+		value, err := getBitFieldValues(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *GeoAddCmd:
+		value, err := redis.Int(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *GeoDistCmd:
+		value, found, err := getFloat(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+		cmd.found = found
+
+	case *GeoSearchCmd:
+		value, err := getGeoResults(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *TimeCmd:
+		value, err := getTime(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *EvalCmd:
+		value, err := conn.Receive()
+		if err != nil {
+			return err
 		}
+
+		cmd.value = value
+
+	case *PublishCmd:
+		value, err := redis.Int(conn.Receive())
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *DoCmd:
+		value, err := conn.Receive()
+		if err != nil {
+			return err
+		}
+
+		cmd.value = Reply{value: value}
+
+	default:
+		return ErrUnsupportedCommand
 	}
 
 	return nil
@@ -482,6 +1540,20 @@ func getTTL(value interface{}, err error) (int, error) {
 	return intVal, nil
 }
 
+func getTime(value interface{}, err error) (time.Time, error) {
+	values, err := redis.Int64s(value, err)
+
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if len(values) != 2 {
+		return time.Time{}, errors.New("unexpected TIME reply")
+	}
+
+	return time.Unix(values[0], values[1]*int64(time.Microsecond)), nil
+}
+
 func getString(value interface{}, err error) (string, bool, error) {
 	stringVal, err := redis.String(value, err)
 
@@ -497,9 +1569,17 @@ func getString(value interface{}, err error) (string, bool, error) {
 }
 
 type Subscribe interface {
+	// GetData blocks until a message is received, and returns nil once
+	// unsubscribed or on error
 	GetData() []byte
 }
 
+// Unsubscriber stops a Subscribe, as the ones of this package do. It is apart
+// from Subscribe for other implementations of Subscribe to keep compiling.
+type Unsubscriber interface {
+	Unsubscribe() error
+}
+
 type SubscribeImpl struct {
 	conn redis.PubSubConn
 }
@@ -509,8 +1589,16 @@ func (s *SubscribeImpl) GetData() []byte {
 		switch v := s.conn.Receive().(type) {
 		case redis.Message:
 			return v.Data
+		case redis.Subscription:
+			if v.Kind == "unsubscribe" && v.Count == 0 {
+				return nil
+			}
 		case error:
 			return nil
 		}
 	}
 }
+
+func (s *SubscribeImpl) Unsubscribe() error {
+	return s.conn.Unsubscribe()
+}
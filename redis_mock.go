@@ -1,7 +1,12 @@
 package redis
 
 import (
-	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
 )
 
 func MockRedis() *RedisMock {
@@ -13,9 +18,67 @@ func MockRedis() *RedisMock {
 		failsOnSet: make(map[string]bool),
 		failsOnDel: make(map[string]bool),
 
-		channels: make(map[string]chan []byte),
+		channels: make(map[string][]*SubscribeMock),
 		now:      0,
+
+		pushed:      make(chan struct{}),
+		scanCursors: make(map[int]mockScanCursor),
+
+		scripts: map[string]MockScript{
+			fixedWindowScript.Hash():   mockFixedWindow,
+			slidingWindowScript.Hash(): mockSlidingWindow,
+			tokenBucketScript.Hash():   mockTokenBucket,
+		},
+
+		commands: mockCommands(),
+
+		transient: make(map[string]*mockTransientFailure),
+		random:    newMockRandom(),
+	}
+}
+
+// mockFailure is the error of the failures injected by FailsOnGet, FailsOnSet
+// and FailsOnDel
+func mockFailure(op string) error {
+	return fmt.Errorf("%w: fails on %s", ErrConnection, op)
+}
+
+// MockScript emulates a lua script on a mock connection, as RedisMock can't
+// run lua. It runs without holding the mock lock, so it can call conn methods
+type MockScript func(conn *RedisConnectionMock, keys []string, args []interface{}) (interface{}, error)
+
+// mockValue converts strings and ints to the byte strings redis stores
+func mockValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return []byte(v)
+	case int:
+		return []byte(strconv.Itoa(v))
 	}
+
+	return value
+}
+
+// mockInt parses the integer stored as a byte string, as INCRBY does
+func mockInt(data interface{}) (int, error) {
+	b, ok := data.([]byte)
+
+	if !ok {
+		return 0, mockWrongType()
+	}
+
+	v, err := strconv.Atoi(string(b))
+
+	if err != nil {
+		return 0, mockError("ERR value is not an integer or out of range")
+	}
+
+	return v, nil
+}
+
+// mockWrongType is the error replied for a key holding another type
+func mockWrongType() error {
+	return mockError("WRONGTYPE Operation against a key holding the wrong kind of value")
 }
 
 type RedisMockObject struct {
@@ -24,6 +87,7 @@ type RedisMockObject struct {
 }
 
 type RedisMock struct {
+	mu sync.Mutex
 	db map[string]*RedisMockObject
 
 	failsOnGet map[string]bool
@@ -32,38 +96,99 @@ type RedisMock struct {
 	now        int // in sec
 
 	openedConnections int
-	channels          map[string]chan []byte
+	channels          map[string][]*SubscribeMock
+
+	// connections and pipelines number the ones opened and executed, for
+	// the commands recorded to identify them
+	connections int
+	pipelines   int
+	recorded    []RecordedCommand
+
+	scripts  map[string]MockScript
+	commands map[string]MockCommand
+	hooks    []Hook
+
+	// transient are the failures of commands injected by FailsTransiently
+	transient map[string]*mockTransientFailure
+	retry     RetryPolicy
+	breaker   *circuitBreaker
+
+	// faults are injected by InjectFault, fired lists the ones fired
+	faults []*mockFault
+	fired  []FiredFault
+	random *rand.Rand
+
+	// pushed is closed and replaced on every list or stream push, waking up
+	// blocked reads
+	pushed chan struct{}
+
+	// scanCursors holds the SCAN cursors of the iterations not finished yet,
+	// by id, scanCursor being the last id given
+	scanCursors map[int]mockScanCursor
+	scanCursor  int
+}
+
+// mockScanCursor is the last key returned by a SCAN call of an iteration,
+// the iteration being numbered by its first cursor
+type mockScanCursor struct {
+	after     string
+	iteration int
 }
 
+// maxMockScanCursors bounds the cursors held for iterations given up before
+// their end
+const maxMockScanCursors = 1000
+
 func (r *RedisMock) Connection() RedisConnection {
-	r.openedConnections++
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	return &RedisConnectionMock{
+	r.openedConnections++
+	r.connections++
+	conn := &RedisConnectionMock{
 		redis: r,
+		id:    r.connections,
 	}
+
+	return &hookedConnectionMock{RedisConnectionMock: conn, retry: r.retry}
 }
 
 func (r *RedisMock) FailsOnGet(key string, fails bool) *RedisMock {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	r.failsOnGet[key] = fails
 	return r
 }
 
 func (r *RedisMock) FailsOnSet(key string, fails bool) *RedisMock {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	r.failsOnSet[key] = fails
 	return r
 }
 
 func (r *RedisMock) FailsOnDel(key string, fails bool) *RedisMock {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	r.failsOnDel[key] = fails
 	return r
 }
 
 func (r *RedisMock) SetNow(now int) *RedisMock {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	r.now = now
 	return r
 }
 
 func (r *RedisMock) GetNumKeys() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	num := 0
 	for _, obj := range r.db {
 		if obj.expiresAt == 0 || obj.expiresAt > r.now {
@@ -75,17 +200,32 @@ func (r *RedisMock) GetNumKeys() int {
 }
 
 func (r *RedisMock) With(key string, value interface{}, ttl int) *RedisMock {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	r.set(key, value, ttl)
 	return r
 }
 
+// WithScript registers the go emulation run when script is evaluated
+func (r *RedisMock) WithScript(script *Script, fn MockScript) *RedisMock {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.scripts[script.Hash()] = fn
+	return r
+}
+
 func (r *RedisMock) GetOpenedConnections() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	return r.openedConnections
 }
 
 func (r *RedisMock) get(key string) (interface{}, bool, error) {
 	if r.failsOnGet[key] {
-		return nil, false, errors.New("fails on get")
+		return nil, false, mockFailure("get")
 	}
 
 	redisMockObject := r.db[key]
@@ -97,9 +237,26 @@ func (r *RedisMock) get(key string) (interface{}, bool, error) {
 	return redisMockObject.data, true, nil
 }
 
+// lookup returns the object stored at key, nil if missing or expired
+func (r *RedisMock) lookup(key string) *RedisMockObject {
+	redisMockObject := r.db[key]
+
+	if redisMockObject == nil || redisMockObject.expiresAt != 0 && redisMockObject.expiresAt <= r.now {
+		return nil
+	}
+
+	return redisMockObject
+}
+
+// wakeUp signals blocked reads that something was pushed. Lock must be held.
+func (r *RedisMock) wakeUp() {
+	close(r.pushed)
+	r.pushed = make(chan struct{})
+}
+
 func (r *RedisMock) set(key string, value interface{}, ttl int) error {
 	if r.failsOnSet[key] {
-		return errors.New("fails on set")
+		return mockFailure("set")
 	}
 
 	expiresAt := 0
@@ -109,7 +266,7 @@ func (r *RedisMock) set(key string, value interface{}, ttl int) error {
 	}
 
 	r.db[key] = &RedisMockObject{
-		data:      value,
+		data:      mockValue(value),
 		expiresAt: expiresAt,
 	}
 	return nil
@@ -117,35 +274,63 @@ func (r *RedisMock) set(key string, value interface{}, ttl int) error {
 
 type RedisConnectionMock struct {
 	redis *RedisMock
+	id    int
 }
 
 func (c *RedisConnectionMock) IncrBy(key string, by int) (int, error) {
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
 	value, found, err := c.redis.get(key)
 
 	if err != nil {
 		return 0, err
 	}
 
+	v := 0
+
 	if found {
-		v := value.(int) + by
-		err = c.redis.set(key, v, 0)
-		return v, err
+		if v, err = mockInt(value); err != nil {
+			return 0, err
+		}
 	}
 
-	err = c.redis.set(key, by, 0)
-	return by, err
+	v += by
+	return v, c.redis.set(key, v, 0)
 }
 
 func (c *RedisConnectionMock) Exists(key string) (bool, error) {
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
 	_, found, err := c.redis.get(key)
 	return found, err
 }
 
+func (c *RedisConnectionMock) Type(key string) (string, error) {
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
+	value, found, err := c.redis.get(key)
+
+	if err != nil || !found {
+		return "none", err
+	}
+
+	return mockType(value), nil
+}
+
 func (c *RedisConnectionMock) SetInt(key string, src int, ttl int) error {
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
 	return c.redis.set(key, src, ttl)
 }
 
 func (c *RedisConnectionMock) GetExpire(key string) (int, error) {
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
 	redisMockObject := c.redis.db[key]
 	if redisMockObject == nil || redisMockObject.expiresAt < c.redis.now {
 		return 0, nil
@@ -155,20 +340,27 @@ func (c *RedisConnectionMock) GetExpire(key string) (int, error) {
 }
 
 func (c *RedisConnectionMock) SetExpire(key string, ttl int) error {
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
 	value, found, err := c.redis.get(key)
 
 	if err != nil {
 		return err
 	}
 
+	// EXPIRE replies 0 on a missing key, which the client ignores
 	if !found {
-		return errors.New("can't expire unknown key")
+		return nil
 	}
 
 	return c.redis.set(key, value, ttl)
 }
 
 func (c *RedisConnectionMock) GetInt(key string) (int, bool, error) {
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
 	value, found, err := c.redis.get(key)
 
 	if err != nil {
@@ -179,15 +371,26 @@ func (c *RedisConnectionMock) GetInt(key string) (int, bool, error) {
 		return 0, false, nil
 	}
 
-	v := value.(int)
-	return v, true, err
+	b, ok := value.([]byte)
+
+	if !ok {
+		return 0, false, mockWrongType()
+	}
+
+	return getInt(b, nil)
 }
 
 func (c *RedisConnectionMock) SetString(key string, value string, ttl int) error {
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
 	return c.redis.set(key, value, ttl)
 }
 
 func (c *RedisConnectionMock) GetString(key string) (string, bool, error) {
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
 	value, found, err := c.redis.get(key)
 
 	if err != nil {
@@ -198,22 +401,56 @@ func (c *RedisConnectionMock) GetString(key string) (string, bool, error) {
 		return "", false, nil
 	}
 
-	v := value.(string)
-	return v, true, err
+	b, ok := value.([]byte)
+
+	if !ok {
+		return "", false, mockWrongType()
+	}
+
+	return getString(b, nil)
+}
+
+func (c *RedisConnectionMock) Time() (time.Time, error) {
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
+	return time.Unix(int64(c.redis.now), 0), nil
+}
+
+func (c *RedisConnectionMock) Eval(script *Script, keys []string, args ...interface{}) (interface{}, error) {
+	if len(keys) != script.keyCount {
+		return nil, fmt.Errorf("%w: wrong number of keys", ErrScript)
+	}
+
+	c.redis.mu.Lock()
+	fn := c.redis.scripts[script.Hash()]
+	c.redis.mu.Unlock()
+
+	if fn == nil {
+		return nil, mockError("NOSCRIPT no mock registered for script")
+	}
+
+	return fn(c, keys, args)
 }
 
 func (c *RedisConnectionMock) Close() {
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
 	c.redis.openedConnections--
 }
 
 func (c *RedisConnectionMock) Delete(keys ...string) (int, error) {
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
 	num := 0
 	for _, key := range keys {
 		if c.redis.failsOnDel[key] {
-			return 0, errors.New("fails on del")
+			return 0, mockFailure("del")
 		}
 
-		if c.redis.db[key] != nil {
+		if c.redis.lookup(key) != nil {
 			num++
 		}
 		delete(c.redis.db, key)
@@ -222,6 +459,115 @@ func (c *RedisConnectionMock) Delete(keys ...string) (int, error) {
 	return num, nil
 }
 
+func (c *RedisConnectionMock) Unlink(keys ...string) (int, error) {
+	return c.Delete(keys...)
+}
+
+// Scan walks keys in lexical order, so that keys removed during the iteration
+// don't shift the pages
+func (c *RedisConnectionMock) Scan(options ScanOptions) *ScanIterator {
+	count := options.Count
+
+	if count <= 0 {
+		count = 10
+	}
+
+	return newScanIterator(func(cursor string) (string, []string, error) {
+		c.redis.mu.Lock()
+		defer c.redis.mu.Unlock()
+
+		last := mockScanCursor{iteration: c.redis.scanCursor + 1}
+
+		if cursor != "0" {
+			i, err := strconv.Atoi(cursor)
+			found := false
+
+			if err == nil {
+				last, found = c.redis.scanCursors[i]
+			}
+
+			if !found {
+				return "", nil, mockError("ERR invalid cursor")
+			}
+		}
+
+		c.redis.record(c.id, 0, "SCAN", options.args(cursor))
+
+		keys := make([]string, 0, len(c.redis.db))
+
+		for key := range c.redis.db {
+			if cursor == "0" || key > last.after {
+				keys = append(keys, key)
+			}
+		}
+
+		sort.Strings(keys)
+
+		if len(keys) > count {
+			keys = keys[:count]
+		}
+
+		next := "0"
+
+		if len(keys) == count {
+			next = c.redis.addScanCursor(mockScanCursor{after: keys[len(keys)-1], iteration: last.iteration})
+		} else {
+			c.redis.dropScanCursors(last.iteration)
+		}
+
+		matched := make([]string, 0, len(keys))
+
+		for _, key := range keys {
+			obj := c.redis.lookup(key)
+
+			if obj == nil {
+				continue
+			}
+
+			if len(options.Match) > 0 && !globMatch(options.Match, key) {
+				continue
+			}
+
+			if len(options.Type) > 0 && mockType(obj.data) != options.Type {
+				continue
+			}
+
+			matched = append(matched, key)
+		}
+
+		return next, matched, nil
+	})
+}
+
+// addScanCursor returns the id of a new cursor, evicting the oldest one when
+// too many are held. Lock must be held.
+func (r *RedisMock) addScanCursor(cursor mockScanCursor) string {
+	r.scanCursor++
+	r.scanCursors[r.scanCursor] = cursor
+
+	if len(r.scanCursors) > maxMockScanCursors {
+		oldest := r.scanCursor
+
+		for id := range r.scanCursors {
+			oldest = min(oldest, id)
+		}
+
+		delete(r.scanCursors, oldest)
+	}
+
+	return strconv.Itoa(r.scanCursor)
+}
+
+// dropScanCursors forgets the cursors of a finished iteration. Lock must be
+// held.
+func (r *RedisMock) dropScanCursors(iteration int) {
+	for id, cursor := range r.scanCursors {
+		if cursor.iteration == iteration {
+			delete(r.scanCursors, id)
+		}
+	}
+}
+
 func (c *RedisConnectionMock) Pipeline() Pipeline {
 	return &PipelineMock{
 		conn: c,
@@ -230,20 +576,52 @@ func (c *RedisConnectionMock) Pipeline() Pipeline {
 }
 
 func (c *RedisConnectionMock) Subscribe(channel string) Subscribe {
-	c.redis.channels[channel] = make(chan []byte)
-	return &SubscribeMock{
-		channel: c.redis.channels[channel],
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
+	c.redis.record(c.id, 0, "SUBSCRIBE", []interface{}{channel})
+
+	sub := &SubscribeMock{
+		redis:   c.redis,
+		name:    channel,
+		channel: make(chan []byte, 16),
+		done:    make(chan struct{}),
+	}
+
+	c.redis.channels[channel] = append(c.redis.channels[channel], sub)
+	return sub
+}
+
+// Publish blocks until every subscriber received data or unsubscribed
+func (c *RedisConnectionMock) Publish(channel string, data []byte) (int, error) {
+	c.redis.mu.Lock()
+	subs := c.redis.channels[channel]
+	c.redis.mu.Unlock()
+
+	num := 0
+
+	for _, sub := range subs {
+		select {
+		case sub.channel <- data:
+			num++
+		case <-sub.done:
+		}
 	}
+
+	return num, nil
 }
 
 func (c *RedisConnectionMock) Send(channel string, data []byte) error {
-	c.redis.channels[channel] <- data
-	return nil
+	_, err := c.Publish(channel, data)
+	return err
 }
 
 type PipelineMock struct {
 	conn *RedisConnectionMock
 	cmds []interface{}
+	// single pipelines run a command of a hooked connection
+	single bool
+	retry  RetryPolicy
 }
 
 func (p *PipelineMock) GetInt(key string) *GetIntCmd {
@@ -264,9 +642,11 @@ func (p *PipelineMock) SetInt(key string, value int, ttl int) {
 	p.cmds = append(p.cmds, &cmd)
 }
 
-func (p *PipelineMock) SetExpire(key string, ttl int) {
+func (p *PipelineMock) SetExpire(key string, ttl int) *SetExpireCmd {
 	cmd := SetExpireCmd{key: key, value: ttl}
 	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
 }
 
 func (p *PipelineMock) IncrBy(key string, by int) *IncrByCmd {
@@ -288,92 +668,564 @@ func (p *PipelineMock) SetString(key string, value string, ttl int) {
 	p.cmds = append(p.cmds, &cmd)
 }
 
-func (p *PipelineMock) Delete(key string) *DeleteCmd {
-	cmd := DeleteCmd{key: key}
+func (p *PipelineMock) Exists(key string) *ExistsCmd {
+	cmd := ExistsCmd{key: key}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) Type(key string) *TypeCmd {
+	cmd := TypeCmd{key: key}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) Delete(keys ...string) *DeleteCmd {
+	cmd := DeleteCmd{keys: keys}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) Unlink(keys ...string) *DeleteCmd {
+	cmd := DeleteCmd{keys: keys, unlink: true}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) Time() *TimeCmd {
+	cmd := TimeCmd{}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) Eval(script *Script, keys []string, args ...interface{}) *EvalCmd {
+	cmd := EvalCmd{script: script, keys: keys, args: args}
 	p.cmds = append(p.cmds, &cmd)
 
 	return &cmd
 }
+
+func (p *PipelineMock) Publish(channel string, data []byte) *PublishCmd {
+	cmd := PublishCmd{channel: channel, data: data}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+// Exec runs the commands in order, calling the hooks of the mock around them
+// and retrying them when configured. Like redis, it runs every command and
+// returns the first error as a PipelineError.
 func (p *PipelineMock) Exec() error {
-	for _, cmd := range p.cmds {
-		switch cmd := cmd.(type) {
-		case *GetIntCmd:
-			value, found, err := p.conn.GetInt(cmd.key)
+	return p.execHooked()
+}
 
-			if err != nil {
-				return err
-			}
+// execCmd runs cmd on the mock, without hooks
+func (p *PipelineMock) execCmd(cmd interface{}) error {
+	switch cmd := cmd.(type) {
+	case *GetIntCmd:
+		value, found, err := p.conn.GetInt(cmd.key)
 
-			cmd.value = value
-			cmd.found = found
+		if err != nil {
+			return err
+		}
 
-		case *GetExpireCmd:
-			value, err := p.conn.GetExpire(cmd.key)
+		cmd.value = value
+		cmd.found = found
 
-			if err != nil {
-				return err
-			}
+	case *GetExpireCmd:
+		value, err := p.conn.GetExpire(cmd.key)
 
-			cmd.value = value
+		if err != nil {
+			return err
+		}
 
-		case *SetIntCmd:
-			if err := p.conn.SetInt(cmd.key, cmd.value, cmd.ttl); err != nil {
-				return err
-			}
+		cmd.value = value
 
-		case *SetExpireCmd:
+	case *SetIntCmd:
+		if err := p.conn.SetInt(cmd.key, cmd.value, cmd.ttl); err != nil {
+			return err
+		}
+
+	case *SetExpireCmd:
+		found, err := p.conn.Exists(cmd.key)
+		if err != nil {
+			return err
+		}
+
+		// EXPIRE on a missing key is a no-op replying 0
+		if found {
 			if err := p.conn.SetExpire(cmd.key, cmd.value); err != nil {
 				return err
 			}
+		}
 
-		case *IncrByCmd:
-			value, err := p.conn.IncrBy(cmd.key, cmd.by)
+		cmd.found = found
 
-			if err != nil {
-				return err
-			}
+	case *IncrByCmd:
+		value, err := p.conn.IncrBy(cmd.key, cmd.by)
 
-			cmd.value = value
+		if err != nil {
+			return err
+		}
 
-		case *GetStringCmd:
-			value, found, err := p.conn.GetString(cmd.key)
+		cmd.value = value
 
-			if err != nil {
-				return err
-			}
+	case *GetStringCmd:
+		value, found, err := p.conn.GetString(cmd.key)
 
-			cmd.value = value
-			cmd.found = found
+		if err != nil {
+			return err
+		}
 
-		case *SetStringCmd:
-			if err := p.conn.SetString(cmd.key, cmd.value, cmd.ttl); err != nil {
-				return err
-			}
+		cmd.value = value
+		cmd.found = found
 
-		case *DeleteCmd:
-			num, err := p.conn.Delete(cmd.key)
-			if err != nil {
-				return err
-			}
+	case *SetStringCmd:
+		if err := p.conn.SetString(cmd.key, cmd.value, cmd.ttl); err != nil {
+			return err
+		}
 
-			if num > 0 {
-				cmd.found = true
-			} else {
-				cmd.found = false
-			}
+	case *ExistsCmd:
+		value, err := p.conn.Exists(cmd.key)
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *TypeCmd:
+		value, err := p.conn.Type(cmd.key)
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *DeleteCmd:
+		value, err := p.conn.Delete(cmd.keys...)
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *PushCmd:
+		value, err := p.conn.redis.push(cmd.key, cmd.values, cmd.left)
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *PopCmd:
+		pop := p.conn.RPop
+		if cmd.left {
+			pop = p.conn.LPop
+		}
+
+		value, found, err := pop(cmd.key)
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+		cmd.found = found
+
+	case *LRangeCmd:
+		value, err := p.conn.LRange(cmd.key, cmd.start, cmd.stop)
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
 
-		default:
-			return errors.New("unsupported command")
+	case *LTrimCmd:
+		if err := p.conn.LTrim(cmd.key, cmd.start, cmd.stop); err != nil {
+			return err
 		}
 
+	case *LLenCmd:
+		value, err := p.conn.LLen(cmd.key)
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *BlockingPopCmd:
+		pop := p.conn.BRPop
+		if cmd.left {
+			pop = p.conn.BLPop
+		}
+
+		key, value, found, err := pop(cmd.timeout, cmd.keys...)
+		if err != nil {
+			return err
+		}
+
+		cmd.key = key
+		cmd.value = value
+		cmd.found = found
+
+	case *SAddCmd:
+		value, err := p.conn.SAdd(cmd.key, cmd.members...)
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *SRemCmd:
+		value, err := p.conn.SRem(cmd.key, cmd.members...)
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *SIsMemberCmd:
+		value, err := p.conn.SIsMember(cmd.key, cmd.member)
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *SetMembersCmd:
+		value, err := p.conn.setMembers(cmd.command, cmd.keys)
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *SCardCmd:
+		value, err := p.conn.SCard(cmd.key)
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *ZAddCmd:
+		value, err := p.conn.ZAdd(cmd.key, cmd.members...)
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *ZIncrByCmd:
+		value, err := p.conn.ZIncrBy(cmd.key, cmd.by, cmd.member)
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *ZScoreCmd:
+		value, found, err := p.conn.ZScore(cmd.key, cmd.member)
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+		cmd.found = found
+
+	case *ZRangeCmd:
+		value, err := p.conn.ZRange(cmd.key, cmd.options)
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *ZRangeWithScoresCmd:
+		value, err := p.conn.ZRangeWithScores(cmd.key, cmd.options)
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *ZRankCmd:
+		rank := p.conn.ZRank
+		if cmd.rev {
+			rank = p.conn.ZRevRank
+		}
+
+		value, found, err := rank(cmd.key, cmd.member)
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+		cmd.found = found
+
+	case *ZRemCmd:
+		value, err := p.conn.ZRem(cmd.key, cmd.members...)
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *ZRemRangeByScoreCmd:
+		value, err := p.conn.ZRemRangeByScore(cmd.key, cmd.min, cmd.max)
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *ZCardCmd:
+		value, err := p.conn.ZCard(cmd.key)
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *XAddCmd:
+		value, err := p.conn.XAdd(cmd.stream, cmd.values)
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *XLenCmd:
+		value, err := p.conn.XLen(cmd.stream)
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *XGroupCreateCmd:
+		if err := p.conn.XGroupCreate(cmd.stream, cmd.group, cmd.start, cmd.mkStream); err != nil {
+			return err
+		}
+
+	case *XReadCmd:
+		var value []XStream
+		var err error
+
+		if len(cmd.group) > 0 {
+			value, err = p.conn.XReadGroup(cmd.group, cmd.consumer, cmd.options)
+		} else {
+			value, err = p.conn.XRead(cmd.options)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *XAckCmd:
+		value, err := p.conn.XAck(cmd.stream, cmd.group, cmd.ids...)
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *XPendingCmd:
+		value, err := p.conn.XPending(cmd.stream, cmd.group, cmd.options)
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *XClaimCmd:
+		value, err := p.conn.XClaim(cmd.stream, cmd.group, cmd.consumer, cmd.minIdle, cmd.ids...)
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *PFAddCmd:
+		value, err := p.conn.PFAdd(cmd.key, cmd.elements...)
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *PFCountCmd:
+		value, err := p.conn.PFCount(cmd.keys...)
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *PFMergeCmd:
+		if err := p.conn.PFMerge(cmd.dest, cmd.keys...); err != nil {
+			return err
+		}
+
+	case *SetBitCmd:
+		value, err := p.conn.SetBit(cmd.key, cmd.offset, cmd.bit)
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *GetBitCmd:
+		value, err := p.conn.GetBit(cmd.key, cmd.offset)
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *BitCountCmd:
+		value, err := p.conn.BitCount(cmd.key)
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *BitOpCmd:
+		value, err := p.conn.BitOp(cmd.op, cmd.dest, cmd.keys...)
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *BitFieldCmd:
+		value, err := p.conn.BitField(cmd.key, cmd.ops...)
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *GeoAddCmd:
+		value, err := p.conn.GeoAdd(cmd.key, cmd.locations...)
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *GeoDistCmd:
+		value, found, err := p.conn.GeoDist(cmd.key, cmd.member1, cmd.member2, cmd.unit)
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+		cmd.found = found
+
+	case *GeoSearchCmd:
+		value, err := p.conn.GeoSearch(cmd.key, cmd.options)
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *TimeCmd:
+		value, err := p.conn.Time()
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *EvalCmd:
+		value, err := p.conn.Eval(cmd.script, cmd.keys, cmd.args...)
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *DoCmd:
+		value, err := p.conn.Do(cmd.command, cmd.args...)
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	case *PublishCmd:
+		value, err := p.conn.Publish(cmd.channel, cmd.data)
+		if err != nil {
+			return err
+		}
+
+		cmd.value = value
+
+	default:
+		return ErrUnsupportedCommand
 	}
+
 	return nil
 }
 
+// mockType returns the redis type name of data
+func mockType(data interface{}) string {
+	switch data.(type) {
+	case mockList:
+		return "list"
+	case *mockStream:
+		return "stream"
+	case mockSet:
+		return "set"
+	case mockZSet:
+		return "zset"
+	case mockHLL:
+		return "string"
+	case map[string]string:
+		return "hash"
+	default:
+		return "string"
+	}
+}
+
 type SubscribeMock struct {
+	redis   *RedisMock
+	name    string
 	channel chan []byte
+	done    chan struct{}
 }
 
 func (s *SubscribeMock) GetData() []byte {
-	return <-s.channel
+	select {
+	case data := <-s.channel:
+		return data
+	case <-s.done:
+		return nil
+	}
+}
+
+func (s *SubscribeMock) Unsubscribe() error {
+	s.redis.mu.Lock()
+	defer s.redis.mu.Unlock()
+
+	subs := s.redis.channels[s.name]
+
+	for i, sub := range subs {
+		if sub == s {
+			s.redis.channels[s.name] = append(subs[:i:i], subs[i+1:]...)
+			close(s.done)
+			break
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,137 @@
+// Package redisprom adapts the metrics of gcloud-redis clients to Prometheus
+package redisprom
+
+import (
+	"sync"
+	"time"
+
+	redis "github.com/apinet/gcloud-redis"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics implements redis.Metrics with Prometheus collectors. Being a
+// collector itself, it must be registered:
+//
+//	metrics := redisprom.NewMetrics("myapp")
+//	prometheus.MustRegister(metrics)
+//	r := redis.NewRedis(address, 10, redis.WithMetrics(metrics))
+type Metrics struct {
+	commands     *prometheus.HistogramVec
+	errors       *prometheus.CounterVec
+	pipelines    prometheus.Histogram
+	active       *prometheus.Desc
+	idle         *prometheus.Desc
+	waitCount    *prometheus.Desc
+	waitDuration *prometheus.Desc
+
+	mu    sync.Mutex
+	pools []func() redis.PoolStats
+}
+
+// NewMetrics returns the collectors named namespace_redis_*
+func NewMetrics(namespace string) *Metrics {
+	desc := func(name string, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "redis", name), help, nil, nil)
+	}
+
+	return &Metrics{
+		commands: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "redis",
+			Name:      "command_duration_seconds",
+			Help:      "Duration of redis commands.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		}, []string{"command"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "redis",
+			Name:      "errors_total",
+			Help:      "Failed redis commands and pipelines by type of error.",
+		}, []string{"command", "type"}),
+		pipelines: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "redis",
+			Name:      "pipeline_size",
+			Help:      "Number of commands of redis pipelines.",
+			Buckets:   prometheus.ExponentialBuckets(1, 4, 8),
+		}),
+		active:       desc("pool_active_connections", "Connections of the pool, idle or in use."),
+		idle:         desc("pool_idle_connections", "Idle connections of the pool."),
+		waitCount:    desc("pool_wait_total", "Waits for a connection of the pool."),
+		waitDuration: desc("pool_wait_seconds_total", "Time spent waiting for a connection of the pool."),
+	}
+}
+
+func (m *Metrics) ObserveCommand(command string, duration time.Duration, errType string) {
+	m.commands.WithLabelValues(command).Observe(duration.Seconds())
+
+	if len(errType) > 0 {
+		m.errors.WithLabelValues(command, errType).Inc()
+	}
+}
+
+func (m *Metrics) ObservePipeline(size int, duration time.Duration, errType string) {
+	m.pipelines.Observe(float64(size))
+	m.commands.WithLabelValues("PIPELINE").Observe(duration.Seconds())
+
+	if len(errType) > 0 {
+		m.errors.WithLabelValues("PIPELINE", errType).Inc()
+	}
+}
+
+// ObservePool keeps stats to read the pool at collect time, the stats of
+// every client sharing m being added up
+func (m *Metrics) ObservePool(stats func() redis.PoolStats) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.pools = append(m.pools, stats)
+}
+
+func (m *Metrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{m.commands, m.errors, m.pipelines}
+}
+
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	for _, c := range m.collectors() {
+		c.Describe(ch)
+	}
+
+	ch <- m.active
+	ch <- m.idle
+	ch <- m.waitCount
+	ch <- m.waitDuration
+}
+
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	for _, c := range m.collectors() {
+		c.Collect(ch)
+	}
+
+	stats := m.poolStats()
+	ch <- prometheus.MustNewConstMetric(m.active, prometheus.GaugeValue, float64(stats.ActiveCount))
+	ch <- prometheus.MustNewConstMetric(m.idle, prometheus.GaugeValue, float64(stats.IdleCount))
+	ch <- prometheus.MustNewConstMetric(m.waitCount, prometheus.CounterValue, float64(stats.WaitCount))
+	ch <- prometheus.MustNewConstMetric(m.waitDuration, prometheus.CounterValue, stats.WaitDuration.Seconds())
+}
+
+// poolStats adds up the stats of the pools observed
+func (m *Metrics) poolStats() redis.PoolStats {
+	m.mu.Lock()
+	pools := append([]func() redis.PoolStats{}, m.pools...)
+	m.mu.Unlock()
+
+	total := redis.PoolStats{}
+
+	for _, pool := range pools {
+		stats := pool()
+		total.ActiveCount += stats.ActiveCount
+		total.IdleCount += stats.IdleCount
+		total.WaitCount += stats.WaitCount
+		total.WaitDuration += stats.WaitDuration
+	}
+
+	return total
+}
+
+var _ redis.Metrics = (*Metrics)(nil)
@@ -0,0 +1,67 @@
+package redisprom
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	redis "github.com/apinet/gcloud-redis"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	m := NewMetrics("app")
+	registry := prometheus.NewRegistry()
+	assert.Nil(t, registry.Register(m), "must register")
+
+	m.ObserveCommand("GET", time.Millisecond, "")
+	m.ObserveCommand("GET", time.Millisecond, "WRONGTYPE")
+	m.ObservePipeline(20, 5*time.Millisecond, "")
+
+	stats := redis.PoolStats{ActiveCount: 3, IdleCount: 2, WaitCount: 4, WaitDuration: time.Second}
+	m.ObservePool(func() redis.PoolStats { return stats })
+
+	assert.Equal(t, 2, testutil.CollectAndCount(m.commands), "GET and PIPELINE latencies")
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.errors.WithLabelValues("GET", "WRONGTYPE")))
+
+	pool := `
+# HELP app_redis_pool_active_connections Connections of the pool, idle or in use.
+# TYPE app_redis_pool_active_connections gauge
+app_redis_pool_active_connections %d
+# HELP app_redis_pool_wait_seconds_total Time spent waiting for a connection of the pool.
+# TYPE app_redis_pool_wait_seconds_total counter
+app_redis_pool_wait_seconds_total %d
+# HELP app_redis_pool_wait_total Waits for a connection of the pool.
+# TYPE app_redis_pool_wait_total counter
+app_redis_pool_wait_total %d
+`
+	names := []string{"app_redis_pool_active_connections", "app_redis_pool_wait_total", "app_redis_pool_wait_seconds_total"}
+	err := testutil.GatherAndCompare(registry, strings.NewReader(fmt.Sprintf(pool, 3, 1, 4)), names...)
+	assert.Nil(t, err, "must match")
+
+	stats.ActiveCount = 5
+	stats.WaitCount = 6
+	stats.WaitDuration = 2 * time.Second
+	err = testutil.GatherAndCompare(registry, strings.NewReader(fmt.Sprintf(pool, 5, 2, 6)), names...)
+	assert.Nil(t, err, "must read the pool at collect time")
+
+	err = testutil.CollectAndCompare(m.pipelines, strings.NewReader(`
+# HELP app_redis_pipeline_size Number of commands of redis pipelines.
+# TYPE app_redis_pipeline_size histogram
+app_redis_pipeline_size_bucket{le="1"} 0
+app_redis_pipeline_size_bucket{le="4"} 0
+app_redis_pipeline_size_bucket{le="16"} 0
+app_redis_pipeline_size_bucket{le="64"} 1
+app_redis_pipeline_size_bucket{le="256"} 1
+app_redis_pipeline_size_bucket{le="1024"} 1
+app_redis_pipeline_size_bucket{le="4096"} 1
+app_redis_pipeline_size_bucket{le="16384"} 1
+app_redis_pipeline_size_bucket{le="+Inf"} 1
+app_redis_pipeline_size_sum 20
+app_redis_pipeline_size_count 1
+`))
+	assert.Nil(t, err, "must match")
+}
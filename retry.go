@@ -0,0 +1,226 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// RetryPolicy retries the commands and pipelines safe to send again failing
+// with a transient error: network errors and LOADING, READONLY or TRYAGAIN
+// replies, as during a failover. MOVED isn't retried, as the same node would
+// reply it again.
+type RetryPolicy struct {
+	// MaxAttempts is the max number of attempts of a command, 0 or 1 not
+	// retrying
+	MaxAttempts int
+	// Backoff is the max wait after attempt attempts, the actual wait being
+	// drawn between 0 and it. Defaults to ExponentialBackoff(10ms, 1s).
+	Backoff func(attempt int) time.Duration
+}
+
+// WithRetryPolicy sets the retry policy of connections, which
+// RedisConnection.WithRetry overrides per call
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(o *redisOptions) {
+		o.retry = policy
+	}
+}
+
+// ExponentialBackoff doubles the delay from base on every attempt, up to max
+func ExponentialBackoff(base time.Duration, max time.Duration) func(attempts int) time.Duration {
+	return func(attempts int) time.Duration {
+		delay := base
+
+		for i := 1; i < attempts && delay < max; i++ {
+			delay *= 2
+		}
+
+		if delay > max {
+			return max
+		}
+
+		return delay
+	}
+}
+
+// idempotentCommands can be sent again with the same effect and reply, when
+// the reply of an attempt was lost. Writers replying counts, such as DEL or
+// SADD, aren't, as the count of a retry misses the writes of the first
+// attempt.
+var idempotentCommands = map[string]bool{
+	"PING": true, "TIME": true, "ECHO": true, "TYPE": true,
+	"GET": true, "MGET": true, "EXISTS": true, "TTL": true, "PTTL": true,
+	"SET": true, "SETEX": true, "EXPIRE": true, "SCAN": true,
+	"HGET": true, "HGETALL": true,
+	"LRANGE": true, "LLEN": true, "LINDEX": true,
+	"SISMEMBER": true, "SMEMBERS": true, "SINTER": true, "SUNION": true, "SCARD": true,
+	"ZSCORE": true, "ZRANGE": true, "ZRANK": true, "ZREVRANK": true, "ZCARD": true,
+	"XLEN": true, "XRANGE": true, "XREAD": true, "XPENDING": true,
+	"PFCOUNT": true, "PFMERGE": true,
+	"GETBIT": true, "BITCOUNT": true, "BITOP": true,
+	"GEODIST": true, "GEOPOS": true, "GEOSEARCH": true,
+}
+
+// conditionalArgs make the reply of a command depend on the value it
+// overwrites, such as SET k v NX
+var conditionalArgs = map[string]bool{"NX": true, "XX": true, "GT": true, "LT": true, "GET": true, "INCR": true}
+
+// idempotent tells whether command with args can be sent again, see
+// idempotentCommands
+func idempotent(command string, args []interface{}) bool {
+	if !idempotentCommands[strings.ToUpper(command)] {
+		return false
+	}
+
+	// the first arg is the key, values looking like options are taken as
+	// options to be safe
+	for _, arg := range args[min(1, len(args)):] {
+		var word string
+
+		switch arg := arg.(type) {
+		case string:
+			word = arg
+		case []byte:
+			word = string(arg)
+		}
+
+		if conditionalArgs[strings.ToUpper(word)] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// do runs exec until it succeeds, fails with an error that isn't transient,
+// the attempts are exhausted or ctx is done, calling reset before each retry
+func (p RetryPolicy) do(ctx context.Context, idempotent bool, exec func() error, reset func()) error {
+	for attempt := 1; ; attempt++ {
+		err := exec()
+
+		if err == nil || !idempotent || attempt >= p.MaxAttempts || !isTransient(err) {
+			return err
+		}
+
+		if reset != nil {
+			reset()
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(p.wait(attempt)):
+		}
+	}
+}
+
+func (p RetryPolicy) wait(attempt int) time.Duration {
+	backoff := p.Backoff
+
+	if backoff == nil {
+		backoff = ExponentialBackoff(10*time.Millisecond, time.Second)
+	}
+
+	max := backoff(attempt)
+
+	if max <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(max) + 1))
+}
+
+// isTransient tells whether err may not happen again, once reconnected
+func isTransient(err error) bool {
+	var redisErr redis.Error
+
+	if errors.As(err, &redisErr) {
+		for _, prefix := range []string{"LOADING ", "READONLY ", "TRYAGAIN "} {
+			if strings.HasPrefix(string(redisErr), prefix) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// idempotentCmds tells whether every command of cmds, *XxxCmd, can be sent
+// again
+func idempotentCmds(cmds []interface{}) bool {
+	recorder := &commandConn{}
+
+	if err := sendCmds(recorder, cmds); err != nil {
+		return false
+	}
+
+	for _, cmd := range recorder.cmds {
+		if !idempotent(cmd.Name, cmd.Args) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// retryConn retries the commands sent with Do as policy allows, reconnecting
+// conn before retrying
+type retryConn struct {
+	*instrumentedConn
+	policy RetryPolicy
+}
+
+func (c *retryConn) Do(command string, args ...interface{}) (interface{}, error) {
+	return c.DoContext(context.Background(), command, args...)
+}
+
+func (c *retryConn) DoContext(ctx context.Context, command string, args ...interface{}) (interface{}, error) {
+	var reply interface{}
+
+	err := c.policy.do(ctx, idempotent(command, args), func() error {
+		var err error
+		reply, err = c.instrumentedConn.DoContext(ctx, command, args...)
+
+		return err
+	}, c.reconnect)
+
+	return reply, err
+}
+
+// retryOf returns the retryConn under conn, if any
+func retryOf(conn redis.Conn) (*retryConn, bool) {
+	if c, ok := conn.(*contextConn); ok {
+		conn = c.contextDoer
+	}
+
+	retry, ok := conn.(*retryConn)
+	return retry, ok
+}
+
+// withRetry returns conn retrying with policy, conn as is when policy
+// doesn't retry
+func withRetry(conn redis.Conn, policy RetryPolicy) redis.Conn {
+	if policy.MaxAttempts <= 1 {
+		return instrumented(conn)
+	}
+
+	return &retryConn{instrumentedConn: instrumented(conn), policy: policy}
+}
+
+// WithRetry returns a connection sharing c, retrying with policy
+func (c *RedisConnectionImpl) WithRetry(policy RetryPolicy) RedisConnection {
+	return &RedisConnectionImpl{
+		conn:  withContext(withRetry(c.conn, policy), contextOf(c.conn)),
+		redis: c.redis,
+	}
+}
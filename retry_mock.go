@@ -0,0 +1,68 @@
+package redis
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+type mockTransientFailure struct {
+	attempts int
+	err      error
+}
+
+// FailsTransiently fails the next attempts runs of command, on every
+// connection including the open ones, with err, a LOADING error if nil
+func (r *RedisMock) FailsTransiently(command string, attempts int, err error) *RedisMock {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err == nil {
+		err = redis.Error("LOADING Redis is loading the dataset in memory")
+	}
+
+	r.transient[strings.ToUpper(command)] = &mockTransientFailure{attempts: attempts, err: err}
+	return r
+}
+
+// WithRetry sets the retry policy of the connections opened afterwards
+func (r *RedisMock) WithRetry(policy RetryPolicy) *RedisMock {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.retry = policy
+	return r
+}
+
+// transientFailure returns the error failing this run of command, if any
+func (r *RedisMock) transientFailure(command string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	command = strings.ToUpper(command)
+	failure := r.transient[command]
+
+	if failure == nil {
+		return nil
+	}
+
+	failure.attempts--
+
+	if failure.attempts <= 0 {
+		delete(r.transient, command)
+	}
+
+	return failure.err
+}
+
+// WithRetry returns a connection sharing c, retrying as policy allows
+func (c *RedisConnectionMock) WithRetry(policy RetryPolicy) RedisConnection {
+	return &hookedConnectionMock{RedisConnectionMock: c, retry: policy}
+}
+
+// WithContext returns a connection sharing c, ctx being ignored as the mock
+// doesn't trace
+func (c *RedisConnectionMock) WithContext(ctx context.Context) RedisConnection {
+	return &hookedConnectionMock{RedisConnectionMock: c}
+}
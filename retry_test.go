@@ -0,0 +1,176 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyConn fails Do and Receive with io.EOF while failures remain
+type flakyConn struct {
+	echoDoConn
+	failures *int
+}
+
+func (c *flakyConn) Do(command string, args ...interface{}) (interface{}, error) {
+	if len(command) > 0 && *c.failures > 0 {
+		*c.failures--
+		return nil, io.EOF
+	}
+
+	return c.echoDoConn.Do(command, args...)
+}
+
+func (c *flakyConn) Receive() (interface{}, error) {
+	if *c.failures > 0 {
+		*c.failures--
+		return nil, io.EOF
+	}
+
+	return c.echoDoConn.Receive()
+}
+
+func noBackoff(attempt int) time.Duration {
+	return 0
+}
+
+func TestRetry(t *testing.T) {
+	failures, dials := 0, 0
+	dial := func() (redis.Conn, error) {
+		dials++
+		return &flakyConn{echoDoConn{echoConn{mu: &sync.Mutex{}, flushes: &[]int{}}}, &failures}, nil
+	}
+
+	policy := RetryPolicy{MaxAttempts: 3, Backoff: noBackoff}
+	r := &RedisImpl{pool: &redis.Pool{Dial: dial}, options: newRedisOptions([]Option{WithRetryPolicy(policy)})}
+	conn := r.Connection()
+	defer conn.Close()
+
+	failures = 2
+	value, _, err := conn.GetString("key")
+	assert.Nil(t, err, "must be retried")
+	assert.Equal(t, "key", value)
+	assert.Equal(t, 3, dials, "must reconnect before retrying")
+
+	failures = 3
+	_, _, err = conn.GetString("key")
+	assert.ErrorIs(t, err, io.EOF, "attempts must be exhausted")
+
+	failures = 1
+	_, err = conn.IncrBy("n", 1)
+	assert.ErrorIs(t, err, io.EOF, "non idempotent commands must not be retried")
+
+	failures = 1
+	_, err = conn.WithRetry(RetryPolicy{}).Exists("key")
+	assert.ErrorIs(t, err, io.EOF, "policy must be set per call")
+
+	failures = 1
+	pipe := conn.Pipeline()
+	get := pipe.GetString("a")
+	assert.Nil(t, pipe.Exec(), "pipeline must be retried")
+	assert.Equal(t, "a", get.Value())
+
+	failures = 1
+	pipe = conn.Pipeline()
+	pipe.GetString("a")
+	pipe.IncrBy("n", 1)
+	assert.ErrorIs(t, pipe.Exec(), io.EOF, "pipelines with non idempotent commands must not be retried")
+
+	failures = 1
+	pipe = conn.WithRetry(policy).Pipeline()
+	get = pipe.GetString("a")
+	assert.Nil(t, pipe.Exec(), "pipeline must be retried with the policy of WithRetry")
+	assert.Equal(t, "a", get.Value())
+
+	failures = 1
+	_, err = conn.Delete("a")
+	assert.ErrorIs(t, err, io.EOF, "counts must not be retried")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	slow := RetryPolicy{MaxAttempts: 3, Backoff: func(attempt int) time.Duration { return time.Hour }}
+	failures = 1
+	_, _, err = conn.WithRetry(slow).WithContext(ctx).GetString("key")
+	assert.ErrorIs(t, err, context.Canceled, "backoff must stop with the context")
+}
+
+func TestIdempotent(t *testing.T) {
+	assert.True(t, idempotent("GET", []interface{}{"k"}))
+	assert.True(t, idempotent("set", []interface{}{"k", "v", "EX", 60}))
+	assert.True(t, idempotent("SET", []interface{}{"get", "v"}), "the key isn't an option")
+	assert.False(t, idempotent("SET", []interface{}{"k", "v", "NX"}), "a retry would fail to acquire what the first attempt did")
+	assert.False(t, idempotent("SET", []interface{}{"k", "v", []byte("get")}))
+	assert.False(t, idempotent("EXPIRE", []interface{}{"k", 60, "GT"}))
+	assert.False(t, idempotent("DEL", []interface{}{"k"}), "a retry would count 0 deleted")
+	assert.False(t, idempotent("INCRBY", []interface{}{"k", 1}))
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := ExponentialBackoff(time.Second, 5*time.Second)
+
+	assert.Equal(t, time.Second, backoff(1))
+	assert.Equal(t, 4*time.Second, backoff(3))
+	assert.Equal(t, 5*time.Second, backoff(10))
+}
+
+func TestRetryHooks(t *testing.T) {
+	failures := 0
+	dial := func() (redis.Conn, error) {
+		return &flakyConn{echoDoConn{echoConn{mu: &sync.Mutex{}, flushes: &[]int{}}}, &failures}, nil
+	}
+
+	policy := RetryPolicy{MaxAttempts: 3, Backoff: noBackoff}
+	r := &RedisImpl{pool: &redis.Pool{Dial: dial}, options: newRedisOptions([]Option{WithRetryPolicy(policy), WithHooks(prefixHook{prefix: "app:"})})}
+	conn := r.Connection()
+	defer conn.Close()
+
+	failures = 2
+	value, _, err := conn.GetString("key")
+	assert.Nil(t, err, "must be retried")
+	assert.Equal(t, "app:key", value, "rewrites must not pile up on retries")
+
+	failures = 1
+	pipe := conn.Pipeline()
+	get := pipe.GetString("a")
+	assert.Nil(t, pipe.Exec(), "pipeline must be retried")
+	assert.Equal(t, "app:a", get.Value())
+}
+
+func TestMockRetry(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, Backoff: noBackoff}
+	r := MockRedis().With("key", "value", 0).FailsTransiently("GET", 2, nil)
+	conn := r.Connection()
+
+	_, _, err := conn.GetString("key")
+	assert.Equal(t, "LOADING Redis is loading the dataset in memory", err.Error(), "must fail without retries")
+
+	value, _, err := conn.WithRetry(policy).GetString("key")
+	assert.Nil(t, err, "must be retried")
+	assert.Equal(t, "value", value)
+
+	r.FailsTransiently("INCRBY", 1, nil)
+	_, err = conn.WithRetry(policy).IncrBy("n", 1)
+	assert.NotNil(t, err, "non idempotent commands must not be retried")
+
+	r.WithRetry(policy).FailsTransiently("EXISTS", 2, redis.Error("READONLY You can't write against a read only replica."))
+	pipe := r.Connection().Pipeline()
+	exists := pipe.Exists("key")
+	assert.Nil(t, pipe.Exec(), "pipeline must be retried")
+	assert.True(t, exists.Value())
+}
+
+func TestIsTransient(t *testing.T) {
+	assert.True(t, isTransient(io.EOF))
+	assert.True(t, isTransient(&net.OpError{Op: "read", Err: errors.New("connection reset by peer")}))
+	assert.False(t, isTransient(redis.Error("MOVED 3999 127.0.0.1:6381")), "the same node replies MOVED again")
+	assert.True(t, isTransient(redis.Error("TRYAGAIN Multiple keys request during rehashing of slot")))
+	assert.False(t, isTransient(redis.Error("WRONGTYPE Operation against a key holding the wrong kind of value")))
+	assert.False(t, isTransient(errors.New("fails on get")))
+}
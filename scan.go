@@ -0,0 +1,237 @@
+package redis
+
+type ScanOptions struct {
+	// Match filters keys with a glob-style pattern
+	Match string
+	// Count hints how many keys are looked at per round trip
+	Count int
+	// Type filters keys holding this type, e.g. "string" or "zset"
+	Type string
+}
+
+func (o ScanOptions) args(cursor string) []interface{} {
+	args := []interface{}{cursor}
+
+	if len(o.Match) > 0 {
+		args = append(args, "MATCH", o.Match)
+	}
+
+	if o.Count > 0 {
+		args = append(args, "COUNT", o.Count)
+	}
+
+	if len(o.Type) > 0 {
+		args = append(args, "TYPE", o.Type)
+	}
+
+	return args
+}
+
+// scanFunc fetches the page of keys at cursor and returns the next cursor,
+// "0" once the iteration is over
+type scanFunc func(cursor string) (string, []string, error)
+
+// ScanIterator walks keys with SCAN. A key may be returned more than once,
+// keys added or removed during the iteration may or may not be returned.
+type ScanIterator struct {
+	scan   scanFunc
+	cursor string
+	keys   []string
+	key    string
+	done   bool
+	err    error
+}
+
+func newScanIterator(scan scanFunc) *ScanIterator {
+	return &ScanIterator{
+		scan:   scan,
+		cursor: "0",
+	}
+}
+
+// Next moves to the next key, fetching pages as needed. It returns false
+// once all keys were seen or on error.
+func (it *ScanIterator) Next() bool {
+	for len(it.keys) == 0 {
+		if it.done || it.err != nil {
+			return false
+		}
+
+		it.cursor, it.keys, it.err = it.scan(it.cursor)
+
+		if it.err != nil {
+			return false
+		}
+
+		it.done = it.cursor == "0"
+	}
+
+	it.key, it.keys = it.keys[0], it.keys[1:]
+	return true
+}
+
+func (it *ScanIterator) Key() string {
+	return it.key
+}
+
+func (it *ScanIterator) Err() error {
+	return it.err
+}
+
+// All returns the keys as a sequence, usable with range over func:
+//
+//	for key, err := range conn.Scan(options).All() {
+func (it *ScanIterator) All() func(yield func(string, error) bool) {
+	return func(yield func(string, error) bool) {
+		for it.Next() {
+			if !yield(it.Key(), nil) {
+				return
+			}
+		}
+
+		if it.err != nil {
+			yield("", it.err)
+		}
+	}
+}
+
+const deleteByPatternBatch = 500
+
+// DeleteByPattern unlinks keys matching a glob-style pattern, in batches, and
+// returns the number of keys removed
+func DeleteByPattern(pattern string, conn RedisConnection) (int, error) {
+	it := conn.Scan(ScanOptions{Match: pattern, Count: deleteByPatternBatch})
+
+	num := 0
+	batch := make([]string, 0, deleteByPatternBatch)
+
+	unlink := func() error {
+		n, err := conn.Unlink(batch...)
+		num += n
+		batch = batch[:0]
+		return err
+	}
+
+	for it.Next() {
+		batch = append(batch, it.Key())
+
+		if len(batch) == deleteByPatternBatch {
+			if err := unlink(); err != nil {
+				return num, err
+			}
+		}
+	}
+
+	if err := it.Err(); err != nil {
+		return num, err
+	}
+
+	if len(batch) > 0 {
+		if err := unlink(); err != nil {
+			return num, err
+		}
+	}
+
+	return num, nil
+}
+
+// globMatch matches s against a redis glob-style pattern, supporting *, ?,
+// [abc], [^abc], [a-z] and \ escapes
+func globMatch(pattern string, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+
+			if len(pattern) == 1 {
+				return true
+			}
+
+			for i := 0; i <= len(s); i++ {
+				if globMatch(pattern[1:], s[i:]) {
+					return true
+				}
+			}
+
+			return false
+
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+
+			s = s[1:]
+			pattern = pattern[1:]
+
+		case '[':
+			if len(s) == 0 {
+				return false
+			}
+
+			matched, rest := globClass(pattern[1:], s[0])
+
+			if !matched {
+				return false
+			}
+
+			s = s[1:]
+			pattern = rest
+
+		default:
+			if pattern[0] == '\\' && len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+
+			if len(s) == 0 || s[0] != pattern[0] {
+				return false
+			}
+
+			s = s[1:]
+			pattern = pattern[1:]
+		}
+	}
+
+	return len(s) == 0
+}
+
+// globClass matches c against the class starting after '[' and returns the
+// pattern left after the closing ']'
+func globClass(pattern string, c byte) (bool, string) {
+	not := len(pattern) > 0 && pattern[0] == '^'
+
+	if not {
+		pattern = pattern[1:]
+	}
+
+	matched := false
+
+	for len(pattern) > 0 && pattern[0] != ']' {
+		switch {
+		case pattern[0] == '\\' && len(pattern) > 1:
+			matched = matched || pattern[1] == c
+			pattern = pattern[2:]
+
+		case len(pattern) > 2 && pattern[1] == '-' && pattern[2] != ']':
+			start, end := pattern[0], pattern[2]
+
+			if start > end {
+				start, end = end, start
+			}
+
+			matched = matched || c >= start && c <= end
+			pattern = pattern[3:]
+
+		default:
+			matched = matched || pattern[0] == c
+			pattern = pattern[1:]
+		}
+	}
+
+	if len(pattern) > 0 {
+		pattern = pattern[1:]
+	}
+
+	return matched != not, pattern
+}
@@ -0,0 +1,84 @@
+package redis
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScan(t *testing.T) {
+
+	t.Run("glob match", func(t *testing.T) {
+		assert.True(t, globMatch("doc1.*", "doc1.field1"))
+		assert.False(t, globMatch("doc1.*", "doc2.field1"))
+		assert.True(t, globMatch("h?llo", "hello"))
+		assert.True(t, globMatch("h[ae]llo", "hallo"))
+		assert.False(t, globMatch("h[^e]llo", "hello"))
+		assert.True(t, globMatch("h[a-c]llo", "hbllo"))
+		assert.True(t, globMatch("h\\*llo", "h*llo"))
+		assert.False(t, globMatch("h\\*llo", "hello"))
+		assert.True(t, globMatch("*", ""))
+	})
+
+	t.Run("walks pages", func(t *testing.T) {
+		r := MockRedis().
+			With("doc1.a", "1", 0).
+			With("doc1.b", "2", 0).
+			With("doc1.c", 3, 0).
+			With("doc2.a", "4", 0).
+			With("doc1.expired", "5", 10).
+			SetNow(10)
+		conn := r.Connection()
+
+		keys := []string{}
+		it := conn.Scan(ScanOptions{Match: "doc1.*", Count: 2})
+		for it.Next() {
+			keys = append(keys, it.Key())
+		}
+
+		assert.Nil(t, it.Err(), "must succeed")
+		sort.Strings(keys)
+		assert.Equal(t, []string{"doc1.a", "doc1.b", "doc1.c"}, keys)
+
+		keys = []string{}
+		conn.Scan(ScanOptions{Type: "string"}).All()(func(key string, err error) bool {
+			keys = append(keys, key)
+			return len(keys) < 2
+		})
+		assert.Equal(t, 2, len(keys), "must stop when yield returns false")
+	})
+
+	t.Run("forgets cursors", func(t *testing.T) {
+		r := MockRedis().
+			With("a", "1", 0).
+			With("b", "2", 0).
+			With("c", "3", 0)
+		conn := r.Connection()
+
+		it := conn.Scan(ScanOptions{Count: 1})
+		for it.Next() {
+		}
+		assert.Nil(t, it.Err(), "must succeed")
+		assert.Empty(t, r.scanCursors, "finished iterations must be forgotten")
+
+		for i := 0; i < maxMockScanCursors+10; i++ {
+			it = conn.Scan(ScanOptions{Count: 1})
+			it.Next()
+		}
+		assert.Len(t, r.scanCursors, maxMockScanCursors, "the oldest cursors must be evicted")
+	})
+
+	t.Run("delete by pattern", func(t *testing.T) {
+		r := MockRedis().
+			With("doc1.a", "1", 0).
+			With("doc1.b", "2", 0).
+			With("doc2.a", "3", 0)
+		conn := r.Connection()
+
+		num, err := DeleteByPattern("doc1.*", conn)
+		assert.Nil(t, err, "must succeed")
+		assert.Equal(t, 2, num)
+		assert.Equal(t, 1, r.GetNumKeys())
+	})
+}
@@ -0,0 +1,34 @@
+package redis
+
+import (
+	"github.com/gomodule/redigo/redis"
+)
+
+// Script is a lua script run with EVALSHA, falling back to EVAL when the
+// server does not know the script yet
+type Script struct {
+	keyCount int
+	script   *redis.Script
+}
+
+func NewScript(keyCount int, src string) *Script {
+	return &Script{
+		keyCount: keyCount,
+		script:   redis.NewScript(keyCount, src),
+	}
+}
+
+// Hash returns the SHA1 of the script source
+func (s *Script) Hash() string {
+	return s.script.Hash()
+}
+
+func scriptArgs(keys []string, args []interface{}) []interface{} {
+	keysAndArgs := make([]interface{}, 0, len(keys)+len(args))
+
+	for _, key := range keys {
+		keysAndArgs = append(keysAndArgs, key)
+	}
+
+	return append(keysAndArgs, args...)
+}
@@ -0,0 +1,164 @@
+package redis
+
+import (
+	"github.com/gomodule/redigo/redis"
+)
+
+func (c *RedisConnectionImpl) SAdd(key string, members ...string) (int, error) {
+	return redis.Int(c.conn.Do("SADD", keyArgs(key, members)...))
+}
+
+func (c *RedisConnectionImpl) SRem(key string, members ...string) (int, error) {
+	return redis.Int(c.conn.Do("SREM", keyArgs(key, members)...))
+}
+
+func (c *RedisConnectionImpl) SIsMember(key string, member string) (bool, error) {
+	return redis.Bool(c.conn.Do("SISMEMBER", key, member))
+}
+
+func (c *RedisConnectionImpl) SMembers(key string) ([]string, error) {
+	return redis.Strings(c.conn.Do("SMEMBERS", key))
+}
+
+func (c *RedisConnectionImpl) SInter(keys ...string) ([]string, error) {
+	return redis.Strings(c.conn.Do("SINTER", stringArgs(keys)...))
+}
+
+func (c *RedisConnectionImpl) SUnion(keys ...string) ([]string, error) {
+	return redis.Strings(c.conn.Do("SUNION", stringArgs(keys)...))
+}
+
+func (c *RedisConnectionImpl) SCard(key string) (int, error) {
+	return redis.Int(c.conn.Do("SCARD", key))
+}
+
+func (p *PipelineImpl) SAdd(key string, members ...string) *SAddCmd {
+	cmd := SAddCmd{
+		key:     key,
+		members: members,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+func (p *PipelineImpl) SRem(key string, members ...string) *SRemCmd {
+	cmd := SRemCmd{
+		key:     key,
+		members: members,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+func (p *PipelineImpl) SIsMember(key string, member string) *SIsMemberCmd {
+	cmd := SIsMemberCmd{
+		key:    key,
+		member: member,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+func (p *PipelineImpl) SMembers(key string) *SetMembersCmd {
+	cmd := SetMembersCmd{
+		command: "SMEMBERS",
+		keys:    []string{key},
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+func (p *PipelineImpl) SInter(keys ...string) *SetMembersCmd {
+	cmd := SetMembersCmd{
+		command: "SINTER",
+		keys:    keys,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+func (p *PipelineImpl) SUnion(keys ...string) *SetMembersCmd {
+	cmd := SetMembersCmd{
+		command: "SUNION",
+		keys:    keys,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+func (p *PipelineImpl) SCard(key string) *SCardCmd {
+	cmd := SCardCmd{
+		key: key,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+// SAddCmd value is the number of members added
+type SAddCmd struct {
+	key     string
+	members []string
+	value   int
+}
+
+func (s *SAddCmd) Value() int {
+	return s.value
+}
+
+// SRemCmd value is the number of members removed
+type SRemCmd struct {
+	key     string
+	members []string
+	value   int
+}
+
+func (s *SRemCmd) Value() int {
+	return s.value
+}
+
+type SIsMemberCmd struct {
+	key    string
+	member string
+	value  bool
+}
+
+func (s *SIsMemberCmd) Value() bool {
+	return s.value
+}
+
+// SetMembersCmd is a SMEMBERS, SINTER or SUNION
+type SetMembersCmd struct {
+	command string
+	keys    []string
+	value   []string
+}
+
+func (s *SetMembersCmd) Value() []string {
+	return s.value
+}
+
+type SCardCmd struct {
+	key   string
+	value int
+}
+
+func (s *SCardCmd) Value() int {
+	return s.value
+}
+
+func stringArgs(values []string) []interface{} {
+	args := make([]interface{}, 0, len(values))
+
+	for _, value := range values {
+		args = append(args, value)
+	}
+
+	return args
+}
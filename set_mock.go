@@ -0,0 +1,221 @@
+package redis
+
+import (
+	"sort"
+)
+
+// mockSet holds the members of a set
+type mockSet map[string]struct{}
+
+// members returns the members sorted, so that mock replies are stable
+func (s mockSet) members() []string {
+	members := make([]string, 0, len(s))
+
+	for member := range s {
+		members = append(members, member)
+	}
+
+	sort.Strings(members)
+	return members
+}
+
+// getSet returns the set at key, empty if missing. Lock must be held.
+func (r *RedisMock) getSet(key string) (mockSet, error) {
+	if r.failsOnGet[key] {
+		return nil, mockFailure("get")
+	}
+
+	obj := r.lookup(key)
+
+	if obj == nil {
+		return mockSet{}, nil
+	}
+
+	set, ok := obj.data.(mockSet)
+
+	if !ok {
+		return nil, mockWrongType()
+	}
+
+	return set, nil
+}
+
+// storeSet stores set at key, keeping its ttl, and removes the key once
+// empty as redis does. Lock must be held.
+func (r *RedisMock) storeSet(key string, set mockSet) error {
+	if r.failsOnSet[key] {
+		return mockFailure("set")
+	}
+
+	if len(set) == 0 {
+		delete(r.db, key)
+		return nil
+	}
+
+	if obj := r.lookup(key); obj != nil {
+		obj.data = set
+		return nil
+	}
+
+	r.db[key] = &RedisMockObject{data: set}
+	return nil
+}
+
+func (c *RedisConnectionMock) SAdd(key string, members ...string) (int, error) {
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
+	set, err := c.redis.getSet(key)
+
+	if err != nil {
+		return 0, err
+	}
+
+	num := 0
+
+	for _, member := range members {
+		if _, ok := set[member]; !ok {
+			set[member] = struct{}{}
+			num++
+		}
+	}
+
+	return num, c.redis.storeSet(key, set)
+}
+
+func (c *RedisConnectionMock) SRem(key string, members ...string) (int, error) {
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
+	set, err := c.redis.getSet(key)
+
+	if err != nil {
+		return 0, err
+	}
+
+	num := 0
+
+	for _, member := range members {
+		if _, ok := set[member]; ok {
+			delete(set, member)
+			num++
+		}
+	}
+
+	return num, c.redis.storeSet(key, set)
+}
+
+func (c *RedisConnectionMock) SIsMember(key string, member string) (bool, error) {
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
+	set, err := c.redis.getSet(key)
+
+	if err != nil {
+		return false, err
+	}
+
+	_, ok := set[member]
+	return ok, nil
+}
+
+func (c *RedisConnectionMock) SMembers(key string) ([]string, error) {
+	return c.setMembers("SMEMBERS", []string{key})
+}
+
+func (c *RedisConnectionMock) SInter(keys ...string) ([]string, error) {
+	return c.setMembers("SINTER", keys)
+}
+
+func (c *RedisConnectionMock) SUnion(keys ...string) ([]string, error) {
+	return c.setMembers("SUNION", keys)
+}
+
+func (c *RedisConnectionMock) SCard(key string) (int, error) {
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
+	set, err := c.redis.getSet(key)
+	return len(set), err
+}
+
+// setMembers runs SMEMBERS, SINTER or SUNION
+func (c *RedisConnectionMock) setMembers(command string, keys []string) ([]string, error) {
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
+	result := mockSet{}
+
+	for i, key := range keys {
+		set, err := c.redis.getSet(key)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if command == "SINTER" && i > 0 {
+			for member := range result {
+				if _, ok := set[member]; !ok {
+					delete(result, member)
+				}
+			}
+
+			continue
+		}
+
+		for member := range set {
+			result[member] = struct{}{}
+		}
+	}
+
+	return result.members(), nil
+}
+
+func (p *PipelineMock) SAdd(key string, members ...string) *SAddCmd {
+	cmd := SAddCmd{key: key, members: members}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) SRem(key string, members ...string) *SRemCmd {
+	cmd := SRemCmd{key: key, members: members}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) SIsMember(key string, member string) *SIsMemberCmd {
+	cmd := SIsMemberCmd{key: key, member: member}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) SMembers(key string) *SetMembersCmd {
+	cmd := SetMembersCmd{command: "SMEMBERS", keys: []string{key}}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) SInter(keys ...string) *SetMembersCmd {
+	cmd := SetMembersCmd{command: "SINTER", keys: keys}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) SUnion(keys ...string) *SetMembersCmd {
+	cmd := SetMembersCmd{command: "SUNION", keys: keys}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) SCard(key string) *SCardCmd {
+	cmd := SCardCmd{key: key}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
@@ -0,0 +1,74 @@
+package redis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSet(t *testing.T) {
+
+	t.Run("commands", func(t *testing.T) {
+		conn := MockRedis().Connection()
+
+		num, err := conn.SAdd("s1", "a", "b", "c", "a")
+		assert.Nil(t, err, "must succeed")
+		assert.Equal(t, 3, num)
+
+		conn.SAdd("s2", "b", "c", "d")
+
+		num, _ = conn.SRem("s1", "c", "z")
+		assert.Equal(t, 1, num)
+
+		isMember, _ := conn.SIsMember("s1", "a")
+		assert.True(t, isMember, "a must be a member")
+
+		card, _ := conn.SCard("s1")
+		assert.Equal(t, 2, card)
+
+		members, _ := conn.SMembers("s1")
+		assert.Equal(t, []string{"a", "b"}, members)
+
+		members, _ = conn.SInter("s1", "s2")
+		assert.Equal(t, []string{"b"}, members)
+
+		members, _ = conn.SUnion("s1", "s2")
+		assert.Equal(t, []string{"a", "b", "c", "d"}, members)
+	})
+
+	t.Run("pipeline", func(t *testing.T) {
+		conn := MockRedis().Connection()
+
+		pipe := conn.Pipeline()
+		added := pipe.SAdd("s", "a", "b")
+		isMember := pipe.SIsMember("s", "c")
+		card := pipe.SCard("s")
+		members := pipe.SMembers("s")
+
+		assert.Nil(t, pipe.Exec(), "must succeed")
+		assert.Equal(t, 2, added.Value())
+		assert.False(t, isMember.Value(), "c must not be a member")
+		assert.Equal(t, 2, card.Value())
+		assert.Equal(t, []string{"a", "b"}, members.Value())
+	})
+
+	t.Run("snap set fields", func(t *testing.T) {
+		type Doc struct {
+			Tags    []string            `json:"tags" redis:"set"`
+			Viewers map[string]struct{} `json:"viewers" redis:"set"`
+		}
+
+		conn := MockRedis().Connection()
+
+		err := RedisSnap("doc1", &Doc{Tags: []string{"x", "y"}, Viewers: map[string]struct{}{"u1": {}}}, 10, conn)
+		assert.Nil(t, err, "must succeed")
+
+		err = RedisSnap("doc1", &Doc{Tags: []string{"z"}}, 10, conn)
+		assert.Nil(t, err, "must succeed")
+
+		doc := Doc{}
+		RedisSnap("doc1", &doc, 10, conn)
+		assert.Equal(t, []string{"z"}, doc.Tags, "set must be replaced")
+		assert.Equal(t, map[string]struct{}{"u1": {}}, doc.Viewers)
+	})
+}
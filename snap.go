@@ -10,65 +10,63 @@ import (
 var ErrMustBeAPointerOfStruct = errors.New("must be a pointer of struct")
 
 func RedisSnap(id string, with interface{}, ttl int, conn RedisConnection) error {
-	if with == nil {
-		return ErrMustBeAPointerOfStruct
-	}
-
-	v := reflect.ValueOf(with)
+	v, err := structValue(with)
 
-	if v.Kind() != reflect.Ptr {
-		return ErrMustBeAPointerOfStruct
-	}
-
-	v = v.Elem()
-
-	if v.Kind() != reflect.Struct {
-		return ErrMustBeAPointerOfStruct
+	if err != nil {
+		return err
 	}
 
-	numField := v.NumField()
 	cmdsMap := map[int]interface{}{}
 
 	pipe := conn.Pipeline()
 
-	for i := 0; i < numField; i++ {
-		field := v.Type().Field(i)
+	for _, field := range snapFields(id, v) {
+		i := field.index
+		redisId := field.key
 
-		jsonTags := strings.Split(field.Tag.Get("json"), ",")
-		redisTag := field.Tag.Get("redis")
-
-		if len(jsonTags) > 0 && len(redisTag) > 0 {
-			redisId := fmt.Sprintf("%s.%s", id, jsonTags[0])
+		if field.kind == reflect.Int {
+			switch field.redisTag {
+			case "set":
+				if v.Field(i).Int() != 0 {
+					pipe.SetInt(redisId, int(v.Field(i).Int()), ttl)
+				} else {
+					cmdsMap[i] = pipe.GetInt(redisId)
+				}
 
-			if field.Type.Kind() == reflect.Int {
-				switch redisTag {
-				case "set":
-					if v.Field(i).Int() != 0 {
-						pipe.SetInt(redisId, int(v.Field(i).Int()), ttl)
-					} else {
-						cmdsMap[i] = pipe.GetInt(redisId)
-					}
+			case "get":
+				cmdsMap[i] = pipe.GetInt(redisId)
 
-				case "get":
-					cmdsMap[i] = pipe.GetInt(redisId)
+			case "inc":
+				cmdsMap[i] = pipe.IncrBy(redisId, int(v.Field(i).Int()))
+				pipe.SetExpire(redisId, ttl)
+			}
+		}
 
-				case "inc":
-					cmdsMap[i] = pipe.IncrBy(redisId, int(v.Field(i).Int()))
-					pipe.SetExpire(redisId, ttl)
+		if field.kind == reflect.String {
+			switch field.redisTag {
+			case "set":
+				if len(v.Field(i).String()) > 0 {
+					pipe.SetString(redisId, v.Field(i).String(), ttl)
+				} else {
+					cmdsMap[i] = pipe.GetString(redisId)
 				}
+			case "get":
+				cmdsMap[i] = pipe.GetString(redisId)
 			}
+		}
 
-			if field.Type.Kind() == reflect.String {
-				switch redisTag {
-				case "set":
-					if len(v.Field(i).String()) > 0 {
-						pipe.SetString(redisId, v.Field(i).String(), ttl)
-					} else {
-						cmdsMap[i] = pipe.GetString(redisId)
-					}
-				case "get":
-					cmdsMap[i] = pipe.GetString(redisId)
+		if field.kind == reflect.Slice || field.kind == reflect.Map {
+			switch field.redisTag {
+			case "set":
+				if v.Field(i).Len() > 0 {
+					pipe.Delete(redisId)
+					pipe.SAdd(redisId, setFieldMembers(v.Field(i))...)
+					pipe.SetExpire(redisId, ttl)
+				} else {
+					cmdsMap[i] = pipe.SMembers(redisId)
 				}
+			case "get":
+				cmdsMap[i] = pipe.SMembers(redisId)
 			}
 		}
 	}
@@ -85,8 +83,182 @@ func RedisSnap(id string, with interface{}, ttl int, conn RedisConnection) error
 			v.Field(i).Set(reflect.ValueOf(cmdT.Value()))
 		case *GetStringCmd:
 			v.Field(i).Set(reflect.ValueOf(cmdT.Value()))
+		case *SetMembersCmd:
+			setSetField(v.Field(i), cmdT.Value())
 		}
 	}
 
 	return nil
 }
+
+// RedisDelete deletes the keys RedisSnap maps the fields of with to, and
+// returns which fields existed, by json name
+func RedisDelete(id string, with interface{}, conn RedisConnection) (map[string]bool, error) {
+	v, err := structValue(with)
+
+	if err != nil {
+		return nil, err
+	}
+
+	pipe := conn.Pipeline()
+	cmdsMap := map[string]*DeleteCmd{}
+
+	for _, field := range snapFields(id, v) {
+		cmdsMap[field.name] = pipe.Delete(field.key)
+	}
+
+	if err := pipe.Exec(); err != nil {
+		return nil, err
+	}
+
+	existed := make(map[string]bool, len(cmdsMap))
+
+	for name, cmd := range cmdsMap {
+		existed[name] = cmd.Found()
+	}
+
+	return existed, nil
+}
+
+// RedisTouch sets the ttl of the keys RedisSnap maps the fields of with to,
+// and returns which fields existed, by json name
+func RedisTouch(id string, with interface{}, ttl int, conn RedisConnection) (map[string]bool, error) {
+	v, err := structValue(with)
+
+	if err != nil {
+		return nil, err
+	}
+
+	pipe := conn.Pipeline()
+	cmdsMap := map[string]*SetExpireCmd{}
+
+	for _, field := range snapFields(id, v) {
+		cmdsMap[field.name] = pipe.SetExpire(field.key, ttl)
+	}
+
+	if err := pipe.Exec(); err != nil {
+		return nil, err
+	}
+
+	existed := make(map[string]bool, len(cmdsMap))
+
+	for name, cmd := range cmdsMap {
+		existed[name] = cmd.Found()
+	}
+
+	return existed, nil
+}
+
+// snapField is a struct field RedisSnap maps to the "id.name" key
+type snapField struct {
+	index    int
+	name     string
+	key      string
+	redisTag string
+	kind     reflect.Kind
+}
+
+func structValue(with interface{}) (reflect.Value, error) {
+	if with == nil {
+		return reflect.Value{}, ErrMustBeAPointerOfStruct
+	}
+
+	v := reflect.ValueOf(with)
+
+	if v.Kind() != reflect.Ptr {
+		return reflect.Value{}, ErrMustBeAPointerOfStruct
+	}
+
+	v = v.Elem()
+
+	if v.Kind() != reflect.Struct {
+		return reflect.Value{}, ErrMustBeAPointerOfStruct
+	}
+
+	return v, nil
+}
+
+// snapFields lists the fields having both json and redis tags and a
+// supported kind
+func snapFields(id string, v reflect.Value) []snapField {
+	numField := v.NumField()
+	fields := make([]snapField, 0, numField)
+
+	for i := 0; i < numField; i++ {
+		field := v.Type().Field(i)
+
+		jsonTags := strings.Split(field.Tag.Get("json"), ",")
+		redisTag := field.Tag.Get("redis")
+		kind := field.Type.Kind()
+
+		if len(jsonTags) == 0 || len(redisTag) == 0 {
+			continue
+		}
+
+		if kind != reflect.Int && kind != reflect.String && !isSetField(field.Type) {
+			continue
+		}
+
+		fields = append(fields, snapField{
+			index:    i,
+			name:     jsonTags[0],
+			key:      fmt.Sprintf("%s.%s", id, jsonTags[0]),
+			redisTag: redisTag,
+			kind:     kind,
+		})
+	}
+
+	return fields
+}
+
+// isSetField tells whether t is a []string or a map[string]struct{}, both
+// mapped to a redis set
+func isSetField(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.String
+	case reflect.Map:
+		return t.Key().Kind() == reflect.String && t.Elem().Kind() == reflect.Struct && t.Elem().NumField() == 0
+	}
+
+	return false
+}
+
+func setFieldMembers(field reflect.Value) []string {
+	members := make([]string, 0, field.Len())
+
+	if field.Kind() == reflect.Slice {
+		for i := 0; i < field.Len(); i++ {
+			members = append(members, field.Index(i).String())
+		}
+
+		return members
+	}
+
+	for _, key := range field.MapKeys() {
+		members = append(members, key.String())
+	}
+
+	return members
+}
+
+func setSetField(field reflect.Value, members []string) {
+	if field.Kind() == reflect.Slice {
+		slice := reflect.MakeSlice(field.Type(), 0, len(members))
+
+		for _, member := range members {
+			slice = reflect.Append(slice, reflect.ValueOf(member).Convert(field.Type().Elem()))
+		}
+
+		field.Set(slice)
+		return
+	}
+
+	m := reflect.MakeMapWithSize(field.Type(), len(members))
+
+	for _, member := range members {
+		m.SetMapIndex(reflect.ValueOf(member).Convert(field.Type().Key()), reflect.New(field.Type().Elem()).Elem())
+	}
+
+	field.Set(m)
+}
@@ -0,0 +1,51 @@
+package redis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapEntity(t *testing.T) {
+
+	type Doc struct {
+		Name  string `json:"name" redis:"set"`
+		Count int    `json:"count,omitempty" redis:"inc"`
+		Other int    `json:"other"`
+	}
+
+	t.Run("delete", func(t *testing.T) {
+		r := MockRedis()
+		conn := r.Connection()
+
+		err := RedisSnap("doc1", &Doc{Name: "a"}, 10, conn)
+		assert.Nil(t, err, "must succeed")
+
+		existed, err := RedisDelete("doc1", &Doc{}, conn)
+		assert.Nil(t, err, "must succeed")
+		assert.Equal(t, map[string]bool{"name": true, "count": true}, existed)
+		assert.Equal(t, 0, r.GetNumKeys())
+
+		existed, _ = RedisDelete("doc1", &Doc{}, conn)
+		assert.Equal(t, map[string]bool{"name": false, "count": false}, existed)
+	})
+
+	t.Run("touch", func(t *testing.T) {
+		r := MockRedis()
+		conn := r.Connection()
+
+		conn.SetString("doc1.name", "a", 10)
+
+		existed, err := RedisTouch("doc1", &Doc{}, 100, conn)
+		assert.Nil(t, err, "must succeed")
+		assert.Equal(t, map[string]bool{"name": true, "count": false}, existed)
+
+		ttl, _ := conn.GetExpire("doc1.name")
+		assert.Equal(t, 100, ttl)
+	})
+
+	t.Run("must be a pointer of struct", func(t *testing.T) {
+		_, err := RedisTouch("doc1", Doc{}, 100, MockRedis().Connection())
+		assert.Equal(t, ErrMustBeAPointerOfStruct, err)
+	})
+}
@@ -0,0 +1,466 @@
+package redis
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+type XMessage struct {
+	ID     string
+	Values map[string]string
+}
+
+// XStream holds the messages read from a stream
+type XStream struct {
+	Stream   string
+	Messages []XMessage
+}
+
+type XReadOptions struct {
+	Streams []string
+	// IDs are the ids to read after, one per stream: "$" for new messages with
+	// XREAD, ">" for never delivered messages with XREADGROUP
+	IDs   []string
+	Count int
+	// Block waits up to Block for messages, 0 doesn't wait
+	Block time.Duration
+}
+
+func (o XReadOptions) args() []interface{} {
+	args := []interface{}{}
+
+	if o.Count > 0 {
+		args = append(args, "COUNT", o.Count)
+	}
+
+	if o.Block > 0 {
+		args = append(args, "BLOCK", o.Block.Milliseconds())
+	}
+
+	args = append(args, "STREAMS")
+	args = append(args, stringArgs(o.Streams)...)
+	return append(args, stringArgs(o.IDs)...)
+}
+
+func (o XReadOptions) groupArgs(group string, consumer string) []interface{} {
+	return append([]interface{}{"GROUP", group, consumer}, o.args()...)
+}
+
+type XPendingOptions struct {
+	// Idle only lists entries delivered for at least Idle
+	Idle time.Duration
+	// Start and End bound the ids listed, default to "-" and "+"
+	Start string
+	End   string
+	// Count is the max number of entries listed, 10 by default
+	Count int
+	// Consumer only lists entries delivered to this consumer
+	Consumer string
+}
+
+func (o XPendingOptions) args(stream string, group string) []interface{} {
+	args := []interface{}{stream, group}
+
+	if o.Idle > 0 {
+		args = append(args, "IDLE", o.Idle.Milliseconds())
+	}
+
+	start, end := o.Start, o.End
+
+	if len(start) == 0 {
+		start = "-"
+	}
+
+	if len(end) == 0 {
+		end = "+"
+	}
+
+	args = append(args, start, end, o.count())
+
+	if len(o.Consumer) > 0 {
+		args = append(args, o.Consumer)
+	}
+
+	return args
+}
+
+func (o XPendingOptions) count() int {
+	if o.Count <= 0 {
+		return 10
+	}
+
+	return o.Count
+}
+
+// XPendingEntry is a message delivered to a consumer and not acknowledged yet
+type XPendingEntry struct {
+	ID         string
+	Consumer   string
+	Idle       time.Duration
+	Deliveries int
+}
+
+func (c *RedisConnectionImpl) XAdd(stream string, values map[string]string) (string, error) {
+	return redis.String(c.conn.Do("XADD", xAddArgs(stream, values)...))
+}
+
+func (c *RedisConnectionImpl) XLen(stream string) (int, error) {
+	return redis.Int(c.conn.Do("XLEN", stream))
+}
+
+// XGroupCreate creates group on stream, delivering messages after start, "$"
+// for new messages only. The stream is created if missing when mkStream.
+func (c *RedisConnectionImpl) XGroupCreate(stream string, group string, start string, mkStream bool) error {
+	_, err := c.conn.Do("XGROUP", xGroupCreateArgs(stream, group, start, mkStream)...)
+	return err
+}
+
+func (c *RedisConnectionImpl) XRead(options XReadOptions) ([]XStream, error) {
+	return getXStreams(c.conn.Do("XREAD", options.args()...))
+}
+
+func (c *RedisConnectionImpl) XReadGroup(group string, consumer string, options XReadOptions) ([]XStream, error) {
+	return getXStreams(c.conn.Do("XREADGROUP", options.groupArgs(group, consumer)...))
+}
+
+func (c *RedisConnectionImpl) XAck(stream string, group string, ids ...string) (int, error) {
+	return redis.Int(c.conn.Do("XACK", xAckArgs(stream, group, ids)...))
+}
+
+func (c *RedisConnectionImpl) XPending(stream string, group string, options XPendingOptions) ([]XPendingEntry, error) {
+	return getXPending(c.conn.Do("XPENDING", options.args(stream, group)...))
+}
+
+// XClaim takes ownership of pending messages idle for at least minIdle
+func (c *RedisConnectionImpl) XClaim(stream string, group string, consumer string, minIdle time.Duration, ids ...string) ([]XMessage, error) {
+	return getXMessages(c.conn.Do("XCLAIM", xClaimArgs(stream, group, consumer, minIdle, ids)...))
+}
+
+func (p *PipelineImpl) XAdd(stream string, values map[string]string) *XAddCmd {
+	cmd := XAddCmd{
+		stream: stream,
+		values: values,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+func (p *PipelineImpl) XLen(stream string) *XLenCmd {
+	cmd := XLenCmd{
+		stream: stream,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+func (p *PipelineImpl) XGroupCreate(stream string, group string, start string, mkStream bool) *XGroupCreateCmd {
+	cmd := XGroupCreateCmd{
+		stream:   stream,
+		group:    group,
+		start:    start,
+		mkStream: mkStream,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+func (p *PipelineImpl) XRead(options XReadOptions) *XReadCmd {
+	cmd := XReadCmd{
+		options: options,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+func (p *PipelineImpl) XReadGroup(group string, consumer string, options XReadOptions) *XReadCmd {
+	cmd := XReadCmd{
+		group:    group,
+		consumer: consumer,
+		options:  options,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+func (p *PipelineImpl) XPending(stream string, group string, options XPendingOptions) *XPendingCmd {
+	cmd := XPendingCmd{
+		stream:  stream,
+		group:   group,
+		options: options,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+func (p *PipelineImpl) XClaim(stream string, group string, consumer string, minIdle time.Duration, ids ...string) *XClaimCmd {
+	cmd := XClaimCmd{
+		stream:   stream,
+		group:    group,
+		consumer: consumer,
+		minIdle:  minIdle,
+		ids:      ids,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+func (p *PipelineImpl) XAck(stream string, group string, ids ...string) *XAckCmd {
+	cmd := XAckCmd{
+		stream: stream,
+		group:  group,
+		ids:    ids,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+// XAddCmd value is the id of the added message
+type XAddCmd struct {
+	stream string
+	values map[string]string
+	value  string
+}
+
+func (x *XAddCmd) Value() string {
+	return x.value
+}
+
+type XLenCmd struct {
+	stream string
+	value  int
+}
+
+func (x *XLenCmd) Value() int {
+	return x.value
+}
+
+// XAckCmd value is the number of messages acknowledged
+type XAckCmd struct {
+	stream string
+	group  string
+	ids    []string
+	value  int
+}
+
+func (x *XAckCmd) Value() int {
+	return x.value
+}
+
+// XGroupCreateCmd fails the pipeline with a BUSYGROUP error for an existing
+// group
+type XGroupCreateCmd struct {
+	stream   string
+	group    string
+	start    string
+	mkStream bool
+}
+
+// XReadCmd is a XREAD, or a XREADGROUP when group is set
+type XReadCmd struct {
+	group    string
+	consumer string
+	options  XReadOptions
+	value    []XStream
+}
+
+func (x *XReadCmd) Value() []XStream {
+	return x.value
+}
+
+type XPendingCmd struct {
+	stream  string
+	group   string
+	options XPendingOptions
+	value   []XPendingEntry
+}
+
+func (x *XPendingCmd) Value() []XPendingEntry {
+	return x.value
+}
+
+// XClaimCmd value is the messages claimed
+type XClaimCmd struct {
+	stream   string
+	group    string
+	consumer string
+	minIdle  time.Duration
+	ids      []string
+	value    []XMessage
+}
+
+func (x *XClaimCmd) Value() []XMessage {
+	return x.value
+}
+
+// IsBusyGroup tells whether err is returned by XGroupCreate for an existing
+// group
+func IsBusyGroup(err error) bool {
+	var redisErr redis.Error
+	return errors.As(err, &redisErr) && strings.HasPrefix(string(redisErr), "BUSYGROUP")
+}
+
+// xAddArgs sorts fields so that messages are written the same way each time
+func xAddArgs(stream string, values map[string]string) []interface{} {
+	fields := make([]string, 0, len(values))
+
+	for field := range values {
+		fields = append(fields, field)
+	}
+
+	sort.Strings(fields)
+
+	args := make([]interface{}, 0, 2*len(values)+2)
+	args = append(args, stream, "*")
+
+	for _, field := range fields {
+		args = append(args, field, values[field])
+	}
+
+	return args
+}
+
+func xGroupCreateArgs(stream string, group string, start string, mkStream bool) []interface{} {
+	args := []interface{}{"CREATE", stream, group, start}
+
+	if mkStream {
+		args = append(args, "MKSTREAM")
+	}
+
+	return args
+}
+
+func xAckArgs(stream string, group string, ids []string) []interface{} {
+	return append([]interface{}{stream, group}, stringArgs(ids)...)
+}
+
+func xClaimArgs(stream string, group string, consumer string, minIdle time.Duration, ids []string) []interface{} {
+	return append([]interface{}{stream, group, consumer, minIdle.Milliseconds()}, stringArgs(ids)...)
+}
+
+func getXStreams(value interface{}, err error) ([]XStream, error) {
+	values, err := redis.Values(value, err)
+
+	if err == redis.ErrNil {
+		return []XStream{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	streams := make([]XStream, 0, len(values))
+
+	for _, value := range values {
+		pair, err := redis.Values(value, nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(pair) != 2 {
+			return nil, errors.New("unexpected stream reply")
+		}
+
+		name, err := redis.String(pair[0], nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		messages, err := getXMessages(pair[1], nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		streams = append(streams, XStream{Stream: name, Messages: messages})
+	}
+
+	return streams, nil
+}
+
+// getXMessages parses messages, skipping the ones deleted from the stream
+// that redis replies with nil values
+func getXMessages(value interface{}, err error) ([]XMessage, error) {
+	values, err := redis.Values(value, err)
+
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]XMessage, 0, len(values))
+
+	for _, value := range values {
+		entry, err := redis.Values(value, nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(entry) != 2 {
+			return nil, errors.New("unexpected stream message reply")
+		}
+
+		if entry[1] == nil {
+			continue
+		}
+
+		id, err := redis.String(entry[0], nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		fields, err := redis.StringMap(entry[1], nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		messages = append(messages, XMessage{ID: id, Values: fields})
+	}
+
+	return messages, nil
+}
+
+func getXPending(value interface{}, err error) ([]XPendingEntry, error) {
+	values, err := redis.Values(value, err)
+
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]XPendingEntry, 0, len(values))
+
+	for _, value := range values {
+		var entry XPendingEntry
+		var idle int64
+
+		fields, err := redis.Values(value, nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := redis.Scan(fields, &entry.ID, &entry.Consumer, &idle, &entry.Deliveries); err != nil {
+			return nil, err
+		}
+
+		entry.Idle = time.Duration(idle) * time.Millisecond
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,593 @@
+package redis
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// streamID is a "ms-seq" stream message id
+type streamID struct {
+	ms  uint64
+	seq uint64
+}
+
+var maxStreamID = streamID{ms: math.MaxUint64, seq: math.MaxUint64}
+
+// parseStreamID parses "ms-seq" ids, "ms" standing for "ms-0"
+func parseStreamID(s string) (streamID, error) {
+	parts := strings.SplitN(s, "-", 2)
+
+	ms, err := strconv.ParseUint(parts[0], 10, 64)
+
+	if err != nil {
+		return streamID{}, mockError("ERR Invalid stream ID specified as stream command argument")
+	}
+
+	id := streamID{ms: ms}
+
+	if len(parts) == 2 {
+		if id.seq, err = strconv.ParseUint(parts[1], 10, 64); err != nil {
+			return streamID{}, mockError("ERR Invalid stream ID specified as stream command argument")
+		}
+	}
+
+	return id, nil
+}
+
+func (id streamID) String() string {
+	return fmt.Sprintf("%d-%d", id.ms, id.seq)
+}
+
+func (id streamID) less(other streamID) bool {
+	return id.ms < other.ms || id.ms == other.ms && id.seq < other.seq
+}
+
+type mockStreamEntry struct {
+	id     streamID
+	values map[string]string
+}
+
+type mockStream struct {
+	entries []mockStreamEntry
+	last    streamID
+	groups  map[string]*mockGroup
+}
+
+type mockGroup struct {
+	lastDelivered streamID
+	pending       map[streamID]*mockPending
+}
+
+// mockPending is a message delivered to consumer at deliveredAt, in mock sec
+type mockPending struct {
+	consumer    string
+	deliveredAt int
+	deliveries  int
+}
+
+func (s *mockStream) message(id streamID) (XMessage, bool) {
+	for _, entry := range s.entries {
+		if entry.id == id {
+			return XMessage{ID: id.String(), Values: entry.values}, true
+		}
+	}
+
+	return XMessage{}, false
+}
+
+// after returns up to count messages with an id greater than id, all of them
+// when count is 0
+func (s *mockStream) after(id streamID, count int) []mockStreamEntry {
+	entries := []mockStreamEntry{}
+
+	for _, entry := range s.entries {
+		if id.less(entry.id) {
+			entries = append(entries, entry)
+		}
+
+		if count > 0 && len(entries) == count {
+			break
+		}
+	}
+
+	return entries
+}
+
+// getStream returns the stream at key, nil if missing. Lock must be held.
+func (r *RedisMock) getStream(key string) (*mockStream, error) {
+	if r.failsOnGet[key] {
+		return nil, mockFailure("get")
+	}
+
+	obj := r.lookup(key)
+
+	if obj == nil {
+		return nil, nil
+	}
+
+	stream, ok := obj.data.(*mockStream)
+
+	if !ok {
+		return nil, mockWrongType()
+	}
+
+	return stream, nil
+}
+
+// getGroup returns group of the stream at key. Lock must be held.
+func (r *RedisMock) getGroup(key string, group string) (*mockStream, *mockGroup, error) {
+	stream, err := r.getStream(key)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if stream == nil || stream.groups[group] == nil {
+		return nil, nil, mockError(fmt.Sprintf("NOGROUP No such key '%s' or consumer group '%s'", key, group))
+	}
+
+	return stream, stream.groups[group], nil
+}
+
+// createStream stores an empty stream at key. Lock must be held.
+func (r *RedisMock) createStream(key string) (*mockStream, error) {
+	if r.failsOnSet[key] {
+		return nil, mockFailure("set")
+	}
+
+	stream := &mockStream{groups: map[string]*mockGroup{}}
+	r.db[key] = &RedisMockObject{data: stream}
+
+	return stream, nil
+}
+
+// XAdd derives ids from the mock clock, in ms
+func (c *RedisConnectionMock) XAdd(stream string, values map[string]string) (string, error) {
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
+	s, err := c.redis.getStream(stream)
+
+	if err != nil {
+		return "", err
+	}
+
+	if s == nil {
+		if s, err = c.redis.createStream(stream); err != nil {
+			return "", err
+		}
+	}
+
+	id := streamID{ms: uint64(c.redis.now) * 1000}
+
+	if !s.last.less(id) {
+		id = streamID{ms: s.last.ms, seq: s.last.seq + 1}
+	}
+
+	copied := make(map[string]string, len(values))
+
+	for field, value := range values {
+		copied[field] = value
+	}
+
+	s.entries = append(s.entries, mockStreamEntry{id: id, values: copied})
+	s.last = id
+	c.redis.wakeUp()
+
+	return id.String(), nil
+}
+
+func (c *RedisConnectionMock) XLen(stream string) (int, error) {
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
+	s, err := c.redis.getStream(stream)
+
+	if err != nil || s == nil {
+		return 0, err
+	}
+
+	return len(s.entries), nil
+}
+
+func (c *RedisConnectionMock) XGroupCreate(stream string, group string, start string, mkStream bool) error {
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
+	s, err := c.redis.getStream(stream)
+
+	if err != nil {
+		return err
+	}
+
+	if s == nil {
+		if !mkStream {
+			return mockError("ERR The XGROUP subcommand requires the key to exist")
+		}
+
+		if s, err = c.redis.createStream(stream); err != nil {
+			return err
+		}
+	}
+
+	if s.groups[group] != nil {
+		return mockError("BUSYGROUP Consumer Group name already exists")
+	}
+
+	lastDelivered := s.last
+
+	if start != "$" {
+		if lastDelivered, err = parseStreamID(start); err != nil {
+			return err
+		}
+	}
+
+	s.groups[group] = &mockGroup{
+		lastDelivered: lastDelivered,
+		pending:       map[streamID]*mockPending{},
+	}
+
+	return nil
+}
+
+// XRead blocks in real time
+func (c *RedisConnectionMock) XRead(options XReadOptions) ([]XStream, error) {
+	if len(options.Streams) != len(options.IDs) {
+		return nil, mockError("ERR Unbalanced XREAD list of streams")
+	}
+
+	c.redis.mu.Lock()
+	ids := make([]streamID, 0, len(options.IDs))
+
+	// "$" is resolved once, so that messages added while blocked are read
+	for i, rawID := range options.IDs {
+		if rawID != "$" {
+			id, err := parseStreamID(rawID)
+
+			if err != nil {
+				c.redis.mu.Unlock()
+				return nil, err
+			}
+
+			ids = append(ids, id)
+			continue
+		}
+
+		s, err := c.redis.getStream(options.Streams[i])
+
+		if err != nil {
+			c.redis.mu.Unlock()
+			return nil, err
+		}
+
+		id := streamID{}
+
+		if s != nil {
+			id = s.last
+		}
+
+		ids = append(ids, id)
+	}
+	c.redis.mu.Unlock()
+
+	return c.blockingRead(options.Block, func() ([]XStream, error) {
+		streams := []XStream{}
+
+		for i, name := range options.Streams {
+			s, err := c.redis.getStream(name)
+
+			if err != nil {
+				return nil, err
+			}
+
+			if s == nil {
+				continue
+			}
+
+			if entries := s.after(ids[i], options.Count); len(entries) > 0 {
+				streams = append(streams, XStream{Stream: name, Messages: xMessages(entries)})
+			}
+		}
+
+		return streams, nil
+	})
+}
+
+// XReadGroup blocks in real time
+func (c *RedisConnectionMock) XReadGroup(group string, consumer string, options XReadOptions) ([]XStream, error) {
+	if len(options.Streams) != len(options.IDs) {
+		return nil, mockError("ERR Unbalanced XREADGROUP list of streams")
+	}
+
+	return c.blockingRead(options.Block, func() ([]XStream, error) {
+		streams := []XStream{}
+
+		for i, name := range options.Streams {
+			s, g, err := c.redis.getGroup(name, group)
+
+			if err != nil {
+				return nil, err
+			}
+
+			messages := []XMessage{}
+
+			if options.IDs[i] == ">" {
+				for _, entry := range s.after(g.lastDelivered, options.Count) {
+					g.pending[entry.id] = &mockPending{
+						consumer:    consumer,
+						deliveredAt: c.redis.now,
+						deliveries:  1,
+					}
+					g.lastDelivered = entry.id
+					messages = append(messages, XMessage{ID: entry.id.String(), Values: entry.values})
+				}
+			} else {
+				after, err := parseStreamID(options.IDs[i])
+
+				if err != nil {
+					return nil, err
+				}
+
+				// history of the consumer, which never blocks
+				options.Block = 0
+
+				for _, id := range g.sortedPending() {
+					if !after.less(id) || g.pending[id].consumer != consumer {
+						continue
+					}
+
+					if message, ok := s.message(id); ok {
+						messages = append(messages, message)
+					}
+
+					if options.Count > 0 && len(messages) == options.Count {
+						break
+					}
+				}
+			}
+
+			if len(messages) > 0 {
+				streams = append(streams, XStream{Stream: name, Messages: messages})
+			}
+		}
+
+		return streams, nil
+	})
+}
+
+func (c *RedisConnectionMock) XAck(stream string, group string, ids ...string) (int, error) {
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
+	_, g, err := c.redis.getGroup(stream, group)
+
+	if err != nil {
+		return 0, err
+	}
+
+	num := 0
+
+	for _, rawID := range ids {
+		id, err := parseStreamID(rawID)
+
+		if err != nil {
+			return 0, err
+		}
+
+		if g.pending[id] != nil {
+			delete(g.pending, id)
+			num++
+		}
+	}
+
+	return num, nil
+}
+
+func (c *RedisConnectionMock) XPending(stream string, group string, options XPendingOptions) ([]XPendingEntry, error) {
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
+	_, g, err := c.redis.getGroup(stream, group)
+
+	if err != nil {
+		return nil, err
+	}
+
+	start, end := streamID{}, maxStreamID
+
+	if len(options.Start) > 0 && options.Start != "-" {
+		if start, err = parseStreamID(options.Start); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(options.End) > 0 && options.End != "+" {
+		if end, err = parseStreamID(options.End); err != nil {
+			return nil, err
+		}
+	}
+
+	entries := []XPendingEntry{}
+
+	for _, id := range g.sortedPending() {
+		if len(entries) >= options.count() {
+			break
+		}
+
+		pending := g.pending[id]
+		idle := time.Duration(c.redis.now-pending.deliveredAt) * time.Second
+
+		if id.less(start) || end.less(id) || idle < options.Idle {
+			continue
+		}
+
+		if len(options.Consumer) > 0 && pending.consumer != options.Consumer {
+			continue
+		}
+
+		entries = append(entries, XPendingEntry{
+			ID:         id.String(),
+			Consumer:   pending.consumer,
+			Idle:       idle,
+			Deliveries: pending.deliveries,
+		})
+	}
+
+	return entries, nil
+}
+
+func (c *RedisConnectionMock) XClaim(stream string, group string, consumer string, minIdle time.Duration, ids ...string) ([]XMessage, error) {
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
+	s, g, err := c.redis.getGroup(stream, group)
+
+	if err != nil {
+		return nil, err
+	}
+
+	messages := []XMessage{}
+
+	for _, rawID := range ids {
+		id, err := parseStreamID(rawID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		pending := g.pending[id]
+
+		if pending == nil || time.Duration(c.redis.now-pending.deliveredAt)*time.Second < minIdle {
+			continue
+		}
+
+		message, ok := s.message(id)
+
+		// messages deleted from the stream are dropped from the pending list
+		if !ok {
+			delete(g.pending, id)
+			continue
+		}
+
+		pending.consumer = consumer
+		pending.deliveredAt = c.redis.now
+		pending.deliveries++
+		messages = append(messages, message)
+	}
+
+	return messages, nil
+}
+
+func (g *mockGroup) sortedPending() []streamID {
+	ids := make([]streamID, 0, len(g.pending))
+
+	for id := range g.pending {
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i].less(ids[j])
+	})
+
+	return ids
+}
+
+// blockingRead runs read with the lock held until it returns streams or the
+// block duration is over, waiting for pushes in between
+func (c *RedisConnectionMock) blockingRead(block time.Duration, read func() ([]XStream, error)) ([]XStream, error) {
+	var deadline <-chan time.Time
+
+	if block > 0 {
+		timer := time.NewTimer(block)
+		defer timer.Stop()
+
+		deadline = timer.C
+	}
+
+	for {
+		c.redis.mu.Lock()
+		streams, err := read()
+		pushed := c.redis.pushed
+		c.redis.mu.Unlock()
+
+		if err != nil || len(streams) > 0 || deadline == nil {
+			return streams, err
+		}
+
+		select {
+		case <-pushed:
+		case <-deadline:
+			return streams, nil
+		}
+	}
+}
+
+func xMessages(entries []mockStreamEntry) []XMessage {
+	messages := make([]XMessage, 0, len(entries))
+
+	for _, entry := range entries {
+		messages = append(messages, XMessage{ID: entry.id.String(), Values: entry.values})
+	}
+
+	return messages
+}
+
+func (p *PipelineMock) XAdd(stream string, values map[string]string) *XAddCmd {
+	cmd := XAddCmd{stream: stream, values: values}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) XLen(stream string) *XLenCmd {
+	cmd := XLenCmd{stream: stream}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) XGroupCreate(stream string, group string, start string, mkStream bool) *XGroupCreateCmd {
+	cmd := XGroupCreateCmd{stream: stream, group: group, start: start, mkStream: mkStream}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) XRead(options XReadOptions) *XReadCmd {
+	cmd := XReadCmd{options: options}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) XReadGroup(group string, consumer string, options XReadOptions) *XReadCmd {
+	cmd := XReadCmd{group: group, consumer: consumer, options: options}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) XPending(stream string, group string, options XPendingOptions) *XPendingCmd {
+	cmd := XPendingCmd{stream: stream, group: group, options: options}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) XClaim(stream string, group string, consumer string, minIdle time.Duration, ids ...string) *XClaimCmd {
+	cmd := XClaimCmd{stream: stream, group: group, consumer: consumer, minIdle: minIdle, ids: ids}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) XAck(stream string, group string, ids ...string) *XAckCmd {
+	cmd := XAckCmd{stream: stream, group: group, ids: ids}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
@@ -0,0 +1,190 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStream(t *testing.T) {
+
+	t.Run("ids from mock clock", func(t *testing.T) {
+		r := MockRedis()
+		r.SetNow(100)
+		conn := r.Connection()
+
+		id, err := conn.XAdd("events", map[string]string{"type": "created"})
+		assert.Nil(t, err, "must succeed")
+		assert.Equal(t, "100000-0", id)
+
+		id, _ = conn.XAdd("events", map[string]string{"type": "updated"})
+		assert.Equal(t, "100000-1", id)
+
+		r.SetNow(101)
+		id, _ = conn.XAdd("events", map[string]string{"type": "deleted"})
+		assert.Equal(t, "101000-0", id)
+
+		length, _ := conn.XLen("events")
+		assert.Equal(t, 3, length)
+
+		streams, _ := conn.XRead(XReadOptions{Streams: []string{"events"}, IDs: []string{"100000-0"}, Count: 1})
+		assert.Equal(t, []XStream{{Stream: "events", Messages: []XMessage{
+			{ID: "100000-1", Values: map[string]string{"type": "updated"}},
+		}}}, streams)
+	})
+
+	t.Run("blocking read", func(t *testing.T) {
+		r := MockRedis()
+		conn := r.Connection()
+
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			r.Connection().XAdd("events", map[string]string{"n": "1"})
+		}()
+
+		streams, err := conn.XRead(XReadOptions{Streams: []string{"events"}, IDs: []string{"$"}, Block: time.Second})
+		assert.Nil(t, err, "must succeed")
+		assert.Len(t, streams, 1)
+
+		streams, _ = conn.XRead(XReadOptions{Streams: []string{"events"}, IDs: []string{"$"}, Block: 10 * time.Millisecond})
+		assert.Empty(t, streams, "must time out")
+	})
+
+	t.Run("consumer group", func(t *testing.T) {
+		r := MockRedis()
+		conn := r.Connection()
+
+		assert.Nil(t, conn.XGroupCreate("jobs", "workers", "$", true), "must create")
+		assert.True(t, IsBusyGroup(conn.XGroupCreate("jobs", "workers", "$", true)), "must exist")
+
+		conn.XAdd("jobs", map[string]string{"job": "a"})
+		conn.XAdd("jobs", map[string]string{"job": "b"})
+
+		options := XReadOptions{Streams: []string{"jobs"}, IDs: []string{">"}, Count: 1}
+		streams, _ := conn.XReadGroup("workers", "w1", options)
+		first := streams[0].Messages[0]
+		assert.Equal(t, "a", first.Values["job"])
+
+		streams, _ = conn.XReadGroup("workers", "w2", options)
+		assert.Equal(t, "b", streams[0].Messages[0].Values["job"])
+
+		streams, _ = conn.XReadGroup("workers", "w1", options)
+		assert.Empty(t, streams, "all delivered")
+
+		streams, _ = conn.XReadGroup("workers", "w1", XReadOptions{Streams: []string{"jobs"}, IDs: []string{"0"}})
+		assert.Equal(t, []XMessage{first}, streams[0].Messages, "must list history")
+
+		r.SetNow(r.now + 60)
+		pending, _ := conn.XPending("jobs", "workers", XPendingOptions{Consumer: "w1", Count: 10})
+		assert.Equal(t, []XPendingEntry{{ID: first.ID, Consumer: "w1", Idle: time.Minute, Deliveries: 1}}, pending)
+
+		messages, _ := conn.XClaim("jobs", "workers", "w2", 2*time.Minute, first.ID)
+		assert.Empty(t, messages, "not idle long enough")
+
+		messages, _ = conn.XClaim("jobs", "workers", "w2", time.Minute, first.ID)
+		assert.Equal(t, []XMessage{first}, messages)
+
+		pending, _ = conn.XPending("jobs", "workers", XPendingOptions{})
+		assert.Len(t, pending, 2, "must list 10 by default")
+		assert.Equal(t, []interface{}{"jobs", "workers", "-", "+", 10}, XPendingOptions{}.args("jobs", "workers"))
+		assert.Equal(t, "w2", pending[0].Consumer)
+		assert.Equal(t, 2, pending[0].Deliveries)
+
+		pipe := conn.Pipeline()
+		ack := pipe.XAck("jobs", "workers", pending[0].ID, pending[1].ID)
+		assert.Nil(t, pipe.Exec(), "must succeed")
+		assert.Equal(t, 2, ack.Value())
+
+		pending, _ = conn.XPending("jobs", "workers", XPendingOptions{Count: 10})
+		assert.Empty(t, pending)
+	})
+
+	t.Run("consumer", func(t *testing.T) {
+		r := MockRedis()
+		conn := r.Connection()
+
+		conn.XAdd("jobs", map[string]string{"job": "a"})
+		conn.XAdd("jobs", map[string]string{"job": "fail"})
+
+		failed := []string{}
+		consumer := NewConsumer(r, ConsumerOptions{Stream: "jobs", Group: "workers", Name: "w1", OnError: func(message XMessage, err error) {
+			failed = append(failed, message.Values["job"]+": "+err.Error())
+		}})
+		handled := []string{}
+		failing := func(message XMessage) error {
+			handled = append(handled, message.Values["job"])
+
+			if message.Values["job"] == "fail" {
+				return errors.New("failed")
+			}
+
+			return nil
+		}
+
+		num, err := consumer.Process(failing)
+		assert.Equal(t, "failed", err.Error())
+		assert.Equal(t, 1, num)
+		assert.Equal(t, []string{"fail: failed"}, failed, "must report handler errors")
+
+		num, _ = consumer.Process(failing)
+		assert.Equal(t, 0, num, "nothing new nor idle")
+
+		r.SetNow(r.now + 60)
+		num, err = consumer.Process(func(message XMessage) error {
+			handled = append(handled, message.Values["job"])
+			return nil
+		})
+		assert.Nil(t, err, "must succeed")
+		assert.Equal(t, 1, num, "must reclaim")
+		assert.Equal(t, []string{"a", "fail", "fail"}, handled, "must reclaim after a minute by default")
+	})
+
+	t.Run("consumer without redelivery", func(t *testing.T) {
+		r := MockRedis()
+		r.Connection().XAdd("jobs", map[string]string{"job": "fail"})
+
+		consumer := NewConsumer(r, ConsumerOptions{Stream: "jobs", Group: "workers", Name: "w1", ClaimIdle: -1})
+		failing := func(message XMessage) error {
+			return errors.New("failed")
+		}
+
+		_, err := consumer.Process(failing)
+		assert.Equal(t, "failed", err.Error())
+
+		r.SetNow(r.now + 3600)
+		num, err := consumer.Process(failing)
+		assert.Nil(t, err, "must succeed")
+		assert.Equal(t, 0, num, "must not reclaim")
+	})
+
+	t.Run("consumer run", func(t *testing.T) {
+		r := MockRedis()
+		r.Connection().XAdd("jobs", map[string]string{"job": "a"})
+		r.Connection().XAdd("jobs", map[string]string{"job": "fail"})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var failed error
+		consumer := NewConsumer(r, ConsumerOptions{Stream: "jobs", Group: "workers", Name: "w1", OnError: func(message XMessage, err error) {
+			failed = err
+			cancel()
+		}})
+
+		err := consumer.Run(ctx, func(message XMessage) error {
+			if message.Values["job"] == "fail" {
+				return errors.New("failed")
+			}
+
+			return nil
+		})
+		assert.Nil(t, err, "handler errors must not stop the consumer")
+		assert.Equal(t, "failed", failed.Error(), "must report handler errors")
+
+		pending, _ := r.Connection().XPending("jobs", "workers", XPendingOptions{Count: 10})
+		assert.Len(t, pending, 1, "must ack the messages handled")
+	})
+}
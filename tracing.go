@@ -0,0 +1,91 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/apinet/gcloud-redis"
+
+// WithTracerProvider traces every command and pipeline Exec with a span of
+// provider, such as otel.GetTracerProvider()
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(o *redisOptions) {
+		o.tracer = provider.Tracer(tracerName)
+	}
+}
+
+// startSpan starts a span as a child of the span in ctx, nil when not
+// tracing
+func (c *instrumentedConn) startSpan(ctx context.Context, name string, attributes ...attribute.KeyValue) trace.Span {
+	if c.options.tracer == nil {
+		return nil
+	}
+
+	_, span := c.options.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("db.system", "redis")),
+		trace.WithAttributes(attributes...),
+	)
+
+	return span
+}
+
+func endSpan(span trace.Span, err error) {
+	if span == nil {
+		return
+	}
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+// secretCommands are the commands whose args may hold credentials, all
+// masked
+var secretCommands = map[string]bool{
+	"AUTH":   true,
+	"HELLO":  true,
+	"CONFIG": true,
+}
+
+// sanitizeStatement keeps the command and its first arg, usually the key,
+// transformed by redact unless nil, and masks the others which may hold
+// values
+func sanitizeStatement(command string, args []interface{}, redact func(key string) string) string {
+	parts := []string{command}
+	secret := secretCommands[strings.ToUpper(command)]
+
+	for i, arg := range args {
+		if i == 0 && !secret {
+			key := fmt.Sprint(statementArg(arg))
+
+			if redact != nil {
+				key = redact(key)
+			}
+
+			parts = append(parts, key)
+			continue
+		}
+
+		parts = append(parts, "?")
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func statementArg(arg interface{}) interface{} {
+	if b, ok := arg.([]byte); ok {
+		return string(b)
+	}
+
+	return arg
+}
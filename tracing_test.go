@@ -0,0 +1,122 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// failingConn fails every command sent with Do
+type failingConn struct {
+	echoConn
+}
+
+func (c *failingConn) Do(command string, args ...interface{}) (interface{}, error) {
+	return nil, errors.New("ERR boom")
+}
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	flushes := []int{}
+
+	dial := func() (redis.Conn, error) {
+		return &echoConn{mu: &sync.Mutex{}, flushes: &flushes}, nil
+	}
+
+	r := &RedisImpl{pool: &redis.Pool{Dial: dial}, options: newRedisOptions([]Option{WithTracerProvider(provider)})}
+	conn := r.Connection()
+	defer conn.Close()
+
+	conn.SetString("user:1", "secret", 60)
+
+	pipe := conn.Pipeline()
+	pipe.GetString("a")
+	pipe.GetString("b")
+	assert.Nil(t, pipe.Exec(), "must succeed")
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2, "pipelined commands must not have their own span")
+
+	set := spans[0]
+	assert.Equal(t, "SETEX", set.Name())
+	assert.Equal(t, trace.SpanKindClient, set.SpanKind())
+	assert.Contains(t, set.Attributes(), attribute.String("db.system", "redis"))
+	assert.Contains(t, set.Attributes(), attribute.String("db.operation", "SETEX"))
+	assert.Contains(t, set.Attributes(), attribute.String("db.statement", "SETEX user:1 ? ?"))
+
+	exec := spans[1]
+	assert.Equal(t, "PIPELINE", exec.Name())
+	assert.Contains(t, exec.Attributes(), attribute.Int("db.operation.batch.size", 2))
+	assert.Equal(t, codes.Unset, exec.Status().Code)
+
+	failing := &RedisImpl{
+		pool: &redis.Pool{Dial: func() (redis.Conn, error) {
+			return &failingConn{}, nil
+		}},
+		options: newRedisOptions([]Option{WithTracerProvider(provider)}),
+	}
+
+	_, _, err := failing.Connection().GetString("a")
+	assert.NotNil(t, err, "must fail")
+
+	get := recorder.Ended()[2]
+	assert.Equal(t, codes.Error, get.Status().Code)
+	assert.Equal(t, "ERR boom", get.Status().Description)
+}
+
+func TestSanitizeStatement(t *testing.T) {
+	assert.Equal(t, "PING", sanitizeStatement("PING", nil, nil))
+	assert.Equal(t, "GET user:?", sanitizeStatement("GET", []interface{}{"user:42"}, KeyPrefix))
+	assert.Equal(t, "HSET h ? ?", sanitizeStatement("HSET", []interface{}{[]byte("h"), "f", "v"}, nil))
+	assert.Equal(t, "AUTH ?", sanitizeStatement("AUTH", []interface{}{"password"}, nil))
+	assert.Equal(t, "auth ? ?", sanitizeStatement("auth", []interface{}{"user", "password"}, KeyPrefix))
+	assert.Equal(t, "HELLO ? ? ? ?", sanitizeStatement("HELLO", []interface{}{3, "AUTH", "user", "password"}, nil))
+	assert.Equal(t, "CONFIG ? ? ?", sanitizeStatement("CONFIG", []interface{}{"SET", "requirepass", "x"}, nil))
+}
+
+func TestTracingContext(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	dial := func() (redis.Conn, error) {
+		return &echoConn{mu: &sync.Mutex{}, flushes: &[]int{}}, nil
+	}
+
+	r := &RedisImpl{pool: &redis.Pool{Dial: dial}, options: newRedisOptions([]Option{WithTracerProvider(provider), WithRetryPolicy(RetryPolicy{MaxAttempts: 2})})}
+	conn := r.Connection()
+	defer conn.Close()
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "handler")
+	traced := conn.WithContext(ctx)
+
+	traced.SetString("a", "value", 60)
+	traced.WithRetry(RetryPolicy{}).Exists("a")
+
+	pipe := traced.Pipeline()
+	pipe.GetString("a")
+	assert.Nil(t, pipe.Exec(), "must succeed")
+
+	conn.Exists("a")
+	parent.End()
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 5)
+
+	for _, span := range spans[:3] {
+		assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID(), span.Name()+" must be a child of the span in ctx")
+		assert.Equal(t, parent.SpanContext().TraceID(), span.SpanContext().TraceID())
+	}
+
+	assert.Equal(t, "PIPELINE", spans[2].Name())
+	assert.False(t, spans[3].Parent().IsValid(), "commands without ctx must be roots")
+}
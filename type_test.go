@@ -0,0 +1,105 @@
+package redis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestType(t *testing.T) {
+
+	t.Run("types", func(t *testing.T) {
+		r := MockRedis().With("s", "value", 0).With("i", 42, 0)
+		conn := r.Connection()
+
+		conn.LPush("l", "a")
+		conn.SAdd("set", "a")
+		conn.ZAdd("z", Z{Member: "a", Score: 1})
+		conn.XAdd("x", map[string]string{"a": "1"})
+		conn.PFAdd("hll", "a")
+
+		for key, expected := range map[string]string{
+			"s": "string", "i": "string", "l": "list", "set": "set",
+			"z": "zset", "x": "stream", "hll": "string", "missing": "none",
+		} {
+			value, err := conn.Type(key)
+			assert.Nil(t, err, "must succeed")
+			assert.Equal(t, expected, value, key)
+		}
+
+		pipe := conn.Pipeline()
+		l := pipe.Type("l")
+		missing := pipe.Type("missing")
+		assert.Nil(t, pipe.Exec(), "must succeed")
+		assert.Equal(t, "list", l.Value())
+		assert.Equal(t, "none", missing.Value())
+	})
+
+	t.Run("strings and ints", func(t *testing.T) {
+		r := MockRedis().With("s", "value", 0).With("i", 42, 0).With("n", "12", 0)
+		conn := r.Connection()
+
+		value, found, err := conn.GetString("i")
+		assert.Nil(t, err, "ints are byte strings")
+		assert.True(t, found)
+		assert.Equal(t, "42", value)
+
+		num, found, err := conn.GetInt("n")
+		assert.Nil(t, err, "numeric strings are ints")
+		assert.True(t, found)
+		assert.Equal(t, 12, num)
+
+		_, _, err = conn.GetInt("s")
+		assert.NotNil(t, err, "must fail to parse")
+		assert.NotErrorIs(t, err, ErrWrongType)
+
+		_, err = conn.IncrBy("s", 1)
+		assert.EqualError(t, err, "ERR value is not an integer or out of range")
+
+		num, err = conn.IncrBy("n", 3)
+		assert.Nil(t, err, "must succeed")
+		assert.Equal(t, 15, num)
+
+		value, _, _ = conn.GetString("n")
+		assert.Equal(t, "15", value)
+
+		assert.Nil(t, conn.SetExpire("n", 10), "must succeed")
+		num, _, _ = conn.GetInt("n")
+		assert.Equal(t, 15, num, "expire must keep the value")
+		assert.Nil(t, conn.SetExpire("missing", 10), "missing keys must be ignored as EXPIRE does")
+	})
+
+	t.Run("wrong type", func(t *testing.T) {
+		r := MockRedis().With("s", "value", 0)
+		conn := r.Connection()
+		conn.LPush("l", "a")
+
+		_, _, err := conn.GetString("l")
+		assert.ErrorIs(t, err, ErrWrongType)
+
+		_, _, err = conn.GetInt("l")
+		assert.ErrorIs(t, err, ErrWrongType)
+
+		_, err = conn.IncrBy("l", 1)
+		assert.ErrorIs(t, err, ErrWrongType)
+
+		_, err = conn.LPush("s", "a")
+		assert.ErrorIs(t, err, ErrWrongType)
+
+		_, err = conn.SAdd("l", "a")
+		assert.ErrorIs(t, err, ErrWrongType)
+
+		_, err = conn.ZAdd("l", Z{Member: "a", Score: 1})
+		assert.ErrorIs(t, err, ErrWrongType)
+
+		_, err = conn.XLen("l")
+		assert.ErrorIs(t, err, ErrWrongType)
+
+		_, err = conn.GetBit("l", 0)
+		assert.ErrorIs(t, err, ErrWrongType)
+
+		assert.Nil(t, conn.SetString("l", "value", 0), "SET must overwrite any type")
+		value, _ := conn.Type("l")
+		assert.Equal(t, "string", value)
+	})
+}
@@ -0,0 +1,362 @@
+package redis
+
+import (
+	"errors"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// Z is a sorted set member with its score
+type Z struct {
+	Member string
+	Score  float64
+}
+
+type ZRangeOptions struct {
+	// Start and Stop are ranks by default, scores such as 1, "(1" or "+inf"
+	// with ByScore, and lex bounds such as "[a", "(a", "-" or "+" with ByLex.
+	// With Rev, Start is the highest bound.
+	Start interface{}
+	Stop  interface{}
+
+	ByScore bool
+	ByLex   bool
+	Rev     bool
+
+	// Offset and Count limit the members returned with ByScore or ByLex, a
+	// zero Count applies no limit
+	Offset int
+	Count  int
+}
+
+func (o ZRangeOptions) args(key string, withScores bool) []interface{} {
+	args := []interface{}{key, o.Start, o.Stop}
+
+	if o.ByScore {
+		args = append(args, "BYSCORE")
+	}
+
+	if o.ByLex {
+		args = append(args, "BYLEX")
+	}
+
+	if o.Rev {
+		args = append(args, "REV")
+	}
+
+	if o.Count != 0 {
+		args = append(args, "LIMIT", o.Offset, o.Count)
+	}
+
+	if withScores {
+		args = append(args, "WITHSCORES")
+	}
+
+	return args
+}
+
+func (c *RedisConnectionImpl) ZAdd(key string, members ...Z) (int, error) {
+	return redis.Int(c.conn.Do("ZADD", zArgs(key, members)...))
+}
+
+func (c *RedisConnectionImpl) ZIncrBy(key string, by float64, member string) (float64, error) {
+	return redis.Float64(c.conn.Do("ZINCRBY", key, by, member))
+}
+
+func (c *RedisConnectionImpl) ZScore(key string, member string) (float64, bool, error) {
+	return getFloat(c.conn.Do("ZSCORE", key, member))
+}
+
+func (c *RedisConnectionImpl) ZRange(key string, options ZRangeOptions) ([]string, error) {
+	return redis.Strings(c.conn.Do("ZRANGE", options.args(key, false)...))
+}
+
+func (c *RedisConnectionImpl) ZRangeWithScores(key string, options ZRangeOptions) ([]Z, error) {
+	return getZs(c.conn.Do("ZRANGE", options.args(key, true)...))
+}
+
+func (c *RedisConnectionImpl) ZRank(key string, member string) (int, bool, error) {
+	return getInt(c.conn.Do("ZRANK", key, member))
+}
+
+func (c *RedisConnectionImpl) ZRevRank(key string, member string) (int, bool, error) {
+	return getInt(c.conn.Do("ZREVRANK", key, member))
+}
+
+func (c *RedisConnectionImpl) ZRem(key string, members ...string) (int, error) {
+	return redis.Int(c.conn.Do("ZREM", keyArgs(key, members)...))
+}
+
+func (c *RedisConnectionImpl) ZRemRangeByScore(key string, min string, max string) (int, error) {
+	return redis.Int(c.conn.Do("ZREMRANGEBYSCORE", key, min, max))
+}
+
+func (c *RedisConnectionImpl) ZCard(key string) (int, error) {
+	return redis.Int(c.conn.Do("ZCARD", key))
+}
+
+func (p *PipelineImpl) ZAdd(key string, members ...Z) *ZAddCmd {
+	cmd := ZAddCmd{
+		key:     key,
+		members: members,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+func (p *PipelineImpl) ZIncrBy(key string, by float64, member string) *ZIncrByCmd {
+	cmd := ZIncrByCmd{
+		key:    key,
+		by:     by,
+		member: member,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+func (p *PipelineImpl) ZScore(key string, member string) *ZScoreCmd {
+	cmd := ZScoreCmd{
+		key:    key,
+		member: member,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+func (p *PipelineImpl) ZRange(key string, options ZRangeOptions) *ZRangeCmd {
+	cmd := ZRangeCmd{
+		key:     key,
+		options: options,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+func (p *PipelineImpl) ZRangeWithScores(key string, options ZRangeOptions) *ZRangeWithScoresCmd {
+	cmd := ZRangeWithScoresCmd{
+		key:     key,
+		options: options,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+func (p *PipelineImpl) ZRank(key string, member string) *ZRankCmd {
+	cmd := ZRankCmd{
+		key:    key,
+		member: member,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+func (p *PipelineImpl) ZRevRank(key string, member string) *ZRankCmd {
+	cmd := ZRankCmd{
+		key:    key,
+		member: member,
+		rev:    true,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+func (p *PipelineImpl) ZRem(key string, members ...string) *ZRemCmd {
+	cmd := ZRemCmd{
+		key:     key,
+		members: members,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+func (p *PipelineImpl) ZRemRangeByScore(key string, min string, max string) *ZRemRangeByScoreCmd {
+	cmd := ZRemRangeByScoreCmd{
+		key: key,
+		min: min,
+		max: max,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+func (p *PipelineImpl) ZCard(key string) *ZCardCmd {
+	cmd := ZCardCmd{
+		key: key,
+	}
+
+	p.cmds = append(p.cmds, &cmd)
+	return &cmd
+}
+
+// ZAddCmd value is the number of members added
+type ZAddCmd struct {
+	key     string
+	members []Z
+	value   int
+}
+
+func (z *ZAddCmd) Value() int {
+	return z.value
+}
+
+// ZIncrByCmd value is the new score of the member
+type ZIncrByCmd struct {
+	key    string
+	by     float64
+	member string
+	value  float64
+}
+
+func (z *ZIncrByCmd) Value() float64 {
+	return z.value
+}
+
+type ZScoreCmd struct {
+	key    string
+	member string
+	value  float64
+	found  bool
+}
+
+func (z *ZScoreCmd) Value() float64 {
+	return z.value
+}
+
+func (z *ZScoreCmd) Found() bool {
+	return z.found
+}
+
+type ZRangeCmd struct {
+	key     string
+	options ZRangeOptions
+	value   []string
+}
+
+func (z *ZRangeCmd) Value() []string {
+	return z.value
+}
+
+type ZRangeWithScoresCmd struct {
+	key     string
+	options ZRangeOptions
+	value   []Z
+}
+
+func (z *ZRangeWithScoresCmd) Value() []Z {
+	return z.value
+}
+
+// ZRankCmd is a ZRANK or ZREVRANK
+type ZRankCmd struct {
+	key    string
+	member string
+	rev    bool
+	value  int
+	found  bool
+}
+
+func (z *ZRankCmd) Value() int {
+	return z.value
+}
+
+func (z *ZRankCmd) Found() bool {
+	return z.found
+}
+
+// ZRemCmd value is the number of members removed
+type ZRemCmd struct {
+	key     string
+	members []string
+	value   int
+}
+
+func (z *ZRemCmd) Value() int {
+	return z.value
+}
+
+// ZRemRangeByScoreCmd value is the number of members removed
+type ZRemRangeByScoreCmd struct {
+	key   string
+	min   string
+	max   string
+	value int
+}
+
+func (z *ZRemRangeByScoreCmd) Value() int {
+	return z.value
+}
+
+type ZCardCmd struct {
+	key   string
+	value int
+}
+
+func (z *ZCardCmd) Value() int {
+	return z.value
+}
+
+func zArgs(key string, members []Z) []interface{} {
+	args := make([]interface{}, 0, 2*len(members)+1)
+	args = append(args, key)
+
+	for _, member := range members {
+		args = append(args, member.Score, member.Member)
+	}
+
+	return args
+}
+
+func getFloat(value interface{}, err error) (float64, bool, error) {
+	floatVal, err := redis.Float64(value, err)
+
+	if err == redis.ErrNil {
+		return 0, false, nil
+	}
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	return floatVal, true, nil
+}
+
+func getZs(value interface{}, err error) ([]Z, error) {
+	values, err := redis.Values(value, err)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(values)%2 != 0 {
+		return nil, errors.New("unexpected WITHSCORES reply")
+	}
+
+	zs := make([]Z, 0, len(values)/2)
+
+	for i := 0; i < len(values); i += 2 {
+		member, err := redis.String(values[i], nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		score, err := redis.Float64(values[i+1], nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		zs = append(zs, Z{Member: member, Score: score})
+	}
+
+	return zs, nil
+}
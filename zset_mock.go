@@ -0,0 +1,497 @@
+package redis
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// mockZSet holds the members of a sorted set with their score
+type mockZSet map[string]float64
+
+// sorted returns the members by score then by member, as redis orders them
+func (s mockZSet) sorted() []Z {
+	zs := make([]Z, 0, len(s))
+
+	for member, score := range s {
+		zs = append(zs, Z{Member: member, Score: score})
+	}
+
+	sort.Slice(zs, func(i, j int) bool {
+		if zs[i].Score != zs[j].Score {
+			return zs[i].Score < zs[j].Score
+		}
+
+		return zs[i].Member < zs[j].Member
+	})
+
+	return zs
+}
+
+// getZSet returns the sorted set at key, empty if missing. Lock must be held.
+func (r *RedisMock) getZSet(key string) (mockZSet, error) {
+	if r.failsOnGet[key] {
+		return nil, mockFailure("get")
+	}
+
+	obj := r.lookup(key)
+
+	if obj == nil {
+		return mockZSet{}, nil
+	}
+
+	zset, ok := obj.data.(mockZSet)
+
+	if !ok {
+		return nil, mockWrongType()
+	}
+
+	return zset, nil
+}
+
+// storeZSet stores zset at key, keeping its ttl, and removes the key once
+// empty as redis does. Lock must be held.
+func (r *RedisMock) storeZSet(key string, zset mockZSet) error {
+	if r.failsOnSet[key] {
+		return mockFailure("set")
+	}
+
+	if len(zset) == 0 {
+		delete(r.db, key)
+		return nil
+	}
+
+	if obj := r.lookup(key); obj != nil {
+		obj.data = zset
+		return nil
+	}
+
+	r.db[key] = &RedisMockObject{data: zset}
+	return nil
+}
+
+func (c *RedisConnectionMock) ZAdd(key string, members ...Z) (int, error) {
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
+	zset, err := c.redis.getZSet(key)
+
+	if err != nil {
+		return 0, err
+	}
+
+	num := 0
+
+	for _, z := range members {
+		if _, ok := zset[z.Member]; !ok {
+			num++
+		}
+
+		zset[z.Member] = z.Score
+	}
+
+	return num, c.redis.storeZSet(key, zset)
+}
+
+func (c *RedisConnectionMock) ZIncrBy(key string, by float64, member string) (float64, error) {
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
+	zset, err := c.redis.getZSet(key)
+
+	if err != nil {
+		return 0, err
+	}
+
+	zset[member] += by
+	return zset[member], c.redis.storeZSet(key, zset)
+}
+
+func (c *RedisConnectionMock) ZScore(key string, member string) (float64, bool, error) {
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
+	zset, err := c.redis.getZSet(key)
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	score, found := zset[member]
+	return score, found, nil
+}
+
+func (c *RedisConnectionMock) ZRange(key string, options ZRangeOptions) ([]string, error) {
+	zs, err := c.ZRangeWithScores(key, options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]string, 0, len(zs))
+
+	for _, z := range zs {
+		members = append(members, z.Member)
+	}
+
+	return members, nil
+}
+
+func (c *RedisConnectionMock) ZRangeWithScores(key string, options ZRangeOptions) ([]Z, error) {
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
+	zset, err := c.redis.getZSet(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return mockZRange(zset.sorted(), options)
+}
+
+func (c *RedisConnectionMock) ZRank(key string, member string) (int, bool, error) {
+	return c.zrank(key, member, false)
+}
+
+func (c *RedisConnectionMock) ZRevRank(key string, member string) (int, bool, error) {
+	return c.zrank(key, member, true)
+}
+
+func (c *RedisConnectionMock) zrank(key string, member string, rev bool) (int, bool, error) {
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
+	zset, err := c.redis.getZSet(key)
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	zs := zset.sorted()
+
+	for i, z := range zs {
+		if z.Member == member {
+			if rev {
+				return len(zs) - 1 - i, true, nil
+			}
+
+			return i, true, nil
+		}
+	}
+
+	return 0, false, nil
+}
+
+func (c *RedisConnectionMock) ZRem(key string, members ...string) (int, error) {
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
+	zset, err := c.redis.getZSet(key)
+
+	if err != nil {
+		return 0, err
+	}
+
+	num := 0
+
+	for _, member := range members {
+		if _, ok := zset[member]; ok {
+			delete(zset, member)
+			num++
+		}
+	}
+
+	return num, c.redis.storeZSet(key, zset)
+}
+
+func (c *RedisConnectionMock) ZRemRangeByScore(key string, min string, max string) (int, error) {
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
+	zset, err := c.redis.getZSet(key)
+
+	if err != nil {
+		return 0, err
+	}
+
+	zs, err := mockZRange(zset.sorted(), ZRangeOptions{Start: min, Stop: max, ByScore: true})
+
+	if err != nil {
+		return 0, err
+	}
+
+	for _, z := range zs {
+		delete(zset, z.Member)
+	}
+
+	return len(zs), c.redis.storeZSet(key, zset)
+}
+
+func (c *RedisConnectionMock) ZCard(key string) (int, error) {
+	c.redis.mu.Lock()
+	defer c.redis.mu.Unlock()
+
+	zset, err := c.redis.getZSet(key)
+	return len(zset), err
+}
+
+// mockZRange applies ZRANGE options to zs, sorted by score
+func mockZRange(zs []Z, options ZRangeOptions) ([]Z, error) {
+	if !options.ByScore && !options.ByLex {
+		start, err := strconv.Atoi(fmt.Sprint(options.Start))
+
+		if err != nil {
+			return nil, mockError("ERR value is not an integer or out of range")
+		}
+
+		stop, err := strconv.Atoi(fmt.Sprint(options.Stop))
+
+		if err != nil {
+			return nil, mockError("ERR value is not an integer or out of range")
+		}
+
+		if options.Rev {
+			zs = reverseZs(zs)
+		}
+
+		start, stop = mockRange(start, stop, len(zs))
+
+		if start > stop {
+			return []Z{}, nil
+		}
+
+		return zs[start : stop+1], nil
+	}
+
+	min, max := options.Start, options.Stop
+
+	if options.Rev {
+		min, max = max, min
+	}
+
+	var in func(z Z) bool
+
+	if options.ByScore {
+		lower, err := parseScoreBound(min)
+
+		if err != nil {
+			return nil, err
+		}
+
+		upper, err := parseScoreBound(max)
+
+		if err != nil {
+			return nil, err
+		}
+
+		in = func(z Z) bool {
+			return lower.below(z.Score) && upper.above(z.Score)
+		}
+	} else {
+		lower, err := parseLexBound(min)
+
+		if err != nil {
+			return nil, err
+		}
+
+		upper, err := parseLexBound(max)
+
+		if err != nil {
+			return nil, err
+		}
+
+		in = func(z Z) bool {
+			return lower.below(z.Member) && upper.above(z.Member)
+		}
+	}
+
+	matched := []Z{}
+
+	for _, z := range zs {
+		if in(z) {
+			matched = append(matched, z)
+		}
+	}
+
+	if options.Rev {
+		matched = reverseZs(matched)
+	}
+
+	if options.Count == 0 {
+		return matched, nil
+	}
+
+	if options.Offset >= len(matched) {
+		return []Z{}, nil
+	}
+
+	matched = matched[options.Offset:]
+
+	if options.Count > 0 && options.Count < len(matched) {
+		matched = matched[:options.Count]
+	}
+
+	return matched, nil
+}
+
+func reverseZs(zs []Z) []Z {
+	reversed := make([]Z, 0, len(zs))
+
+	for i := len(zs) - 1; i >= 0; i-- {
+		reversed = append(reversed, zs[i])
+	}
+
+	return reversed
+}
+
+// scoreBound is a score range bound such as 1, "(1" or "-inf"
+type scoreBound struct {
+	value     float64
+	exclusive bool
+}
+
+func parseScoreBound(bound interface{}) (scoreBound, error) {
+	s := fmt.Sprint(bound)
+	exclusive := strings.HasPrefix(s, "(")
+
+	if exclusive {
+		s = s[1:]
+	}
+
+	switch s {
+	case "-inf":
+		return scoreBound{value: math.Inf(-1), exclusive: exclusive}, nil
+	case "+inf", "inf":
+		return scoreBound{value: math.Inf(1), exclusive: exclusive}, nil
+	}
+
+	value, err := strconv.ParseFloat(s, 64)
+
+	if err != nil {
+		return scoreBound{}, mockError("ERR min or max is not a float")
+	}
+
+	return scoreBound{value: value, exclusive: exclusive}, nil
+}
+
+func (b scoreBound) below(score float64) bool {
+	return b.value < score || !b.exclusive && b.value == score
+}
+
+func (b scoreBound) above(score float64) bool {
+	return b.value > score || !b.exclusive && b.value == score
+}
+
+// lexBound is a member range bound such as "[a", "(a", "-" or "+"
+type lexBound struct {
+	value     string
+	exclusive bool
+	// infinite is -1 for "-" and 1 for "+"
+	infinite int
+}
+
+func parseLexBound(bound interface{}) (lexBound, error) {
+	s := fmt.Sprint(bound)
+
+	switch {
+	case s == "-":
+		return lexBound{infinite: -1}, nil
+	case s == "+":
+		return lexBound{infinite: 1}, nil
+	case strings.HasPrefix(s, "["):
+		return lexBound{value: s[1:]}, nil
+	case strings.HasPrefix(s, "("):
+		return lexBound{value: s[1:], exclusive: true}, nil
+	}
+
+	return lexBound{}, mockError("ERR min or max not valid string range item")
+}
+
+func (b lexBound) below(member string) bool {
+	if b.infinite != 0 {
+		return b.infinite < 0
+	}
+
+	return b.value < member || !b.exclusive && b.value == member
+}
+
+func (b lexBound) above(member string) bool {
+	if b.infinite != 0 {
+		return b.infinite > 0
+	}
+
+	return b.value > member || !b.exclusive && b.value == member
+}
+
+func (p *PipelineMock) ZAdd(key string, members ...Z) *ZAddCmd {
+	cmd := ZAddCmd{key: key, members: members}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) ZIncrBy(key string, by float64, member string) *ZIncrByCmd {
+	cmd := ZIncrByCmd{key: key, by: by, member: member}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) ZScore(key string, member string) *ZScoreCmd {
+	cmd := ZScoreCmd{key: key, member: member}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) ZRange(key string, options ZRangeOptions) *ZRangeCmd {
+	cmd := ZRangeCmd{key: key, options: options}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) ZRangeWithScores(key string, options ZRangeOptions) *ZRangeWithScoresCmd {
+	cmd := ZRangeWithScoresCmd{key: key, options: options}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) ZRank(key string, member string) *ZRankCmd {
+	cmd := ZRankCmd{key: key, member: member}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) ZRevRank(key string, member string) *ZRankCmd {
+	cmd := ZRankCmd{key: key, member: member, rev: true}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) ZRem(key string, members ...string) *ZRemCmd {
+	cmd := ZRemCmd{key: key, members: members}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) ZRemRangeByScore(key string, min string, max string) *ZRemRangeByScoreCmd {
+	cmd := ZRemRangeByScoreCmd{key: key, min: min, max: max}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
+
+func (p *PipelineMock) ZCard(key string) *ZCardCmd {
+	cmd := ZCardCmd{key: key}
+	p.cmds = append(p.cmds, &cmd)
+
+	return &cmd
+}
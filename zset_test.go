@@ -0,0 +1,87 @@
+package redis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestZSet(t *testing.T) {
+
+	t.Run("ranges", func(t *testing.T) {
+		conn := MockRedis().Connection()
+
+		num, err := conn.ZAdd("z", Z{"a", 1}, Z{"b", 2}, Z{"c", 2}, Z{"d", 3}, Z{"e", 5})
+		assert.Nil(t, err, "must succeed")
+		assert.Equal(t, 5, num)
+
+		members, _ := conn.ZRange("z", ZRangeOptions{Start: 0, Stop: -1})
+		assert.Equal(t, []string{"a", "b", "c", "d", "e"}, members)
+
+		members, _ = conn.ZRange("z", ZRangeOptions{Start: 0, Stop: 1, Rev: true})
+		assert.Equal(t, []string{"e", "d"}, members)
+
+		members, _ = conn.ZRange("z", ZRangeOptions{Start: "(1", Stop: 3, ByScore: true})
+		assert.Equal(t, []string{"b", "c", "d"}, members)
+
+		members, _ = conn.ZRange("z", ZRangeOptions{Start: "+inf", Stop: 2, ByScore: true, Rev: true, Offset: 1, Count: 2})
+		assert.Equal(t, []string{"d", "c"}, members)
+
+		members, _ = conn.ZRange("z", ZRangeOptions{Start: "[b", Stop: "(d", ByLex: true})
+		assert.Equal(t, []string{"b", "c"}, members)
+
+		zs, _ := conn.ZRangeWithScores("z", ZRangeOptions{Start: -1, Stop: -1})
+		assert.Equal(t, []Z{{"e", 5}}, zs)
+	})
+
+	t.Run("updates", func(t *testing.T) {
+		conn := MockRedis().Connection()
+		conn.ZAdd("z", Z{"a", 1}, Z{"b", 2}, Z{"c", 3})
+
+		score, _ := conn.ZIncrBy("z", 5, "a")
+		assert.Equal(t, 6.0, score)
+
+		rank, found, _ := conn.ZRank("z", "a")
+		assert.True(t, found, "a must be ranked")
+		assert.Equal(t, 2, rank)
+
+		num, _ := conn.ZRemRangeByScore("z", "-inf", "(3")
+		assert.Equal(t, 1, num)
+
+		num, _ = conn.ZRem("z", "c", "x")
+		assert.Equal(t, 1, num)
+
+		pipe := conn.Pipeline()
+		card := pipe.ZCard("z")
+		revRank := pipe.ZRevRank("z", "a")
+		missing := pipe.ZScore("z", "x")
+		assert.Nil(t, pipe.Exec(), "must succeed")
+		assert.Equal(t, 1, card.Value())
+		assert.Equal(t, 0, revRank.Value())
+		assert.False(t, missing.Found(), "x must not be found")
+	})
+
+	t.Run("leaderboard", func(t *testing.T) {
+		conn := MockRedis().Connection()
+		board := NewLeaderboard("board")
+
+		for i, member := range []string{"a", "b", "c", "d", "e"} {
+			board.Set(member, float64(i*10), conn)
+		}
+		board.Incr("a", 100, conn)
+
+		top, err := board.Top(2, conn)
+		assert.Nil(t, err, "must succeed")
+		assert.Equal(t, []LeaderboardEntry{{"a", 100, 1}, {"e", 40, 2}}, top)
+
+		entry, found, _ := board.RankOf("c", conn)
+		assert.True(t, found, "c must be ranked")
+		assert.Equal(t, LeaderboardEntry{"c", 20, 4}, entry)
+
+		around, _ := board.AroundMe("e", 1, conn)
+		assert.Equal(t, []LeaderboardEntry{{"a", 100, 1}, {"e", 40, 2}, {"d", 30, 3}}, around)
+
+		_, found, _ = board.RankOf("x", conn)
+		assert.False(t, found, "x must not be ranked")
+	})
+}